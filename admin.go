@@ -0,0 +1,146 @@
+package zlogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// levelRequest is the body of a PUT to the level handler. App or Package select an override instead of the level of
+// the Logger, and TTL, such as "10m", reverts the change once elapsed.
+type levelRequest struct {
+	Level   string `json:"level"`
+	App     string `json:"app,omitempty"`
+	Package string `json:"package,omitempty"`
+	TTL     string `json:"ttl,omitempty"`
+}
+
+// levelResponse is the body of the responses of the level handler.
+type levelResponse struct {
+	Level    string               `json:"level"`
+	Apps     map[string]string    `json:"apps,omitempty"`
+	Packages map[string]string    `json:"packages,omitempty"`
+	Reverts  map[string]time.Time `json:"reverts,omitempty"`
+}
+
+// pendingRevert is a level change to revert once its TTL has elapsed.
+type pendingRevert struct {
+	timer    *time.Timer
+	at       time.Time
+	original string
+}
+
+// levelHandler is the http.Handler returned by Logger.LevelHandler.
+type levelHandler struct {
+	logger  *Logger
+	mu      sync.Mutex
+	reverts map[string]*pendingRevert
+}
+
+// LevelHandler returns an http.Handler exposing the level of the Logger and the level overrides. GET returns them,
+// PUT changes one of them from a JSON body such as {"level":"debug","ttl":"10m"}, optionally with "app" or "package"
+// to set an override, reverting the change after the TTL when given. An empty level removes an override.
+func (l *Logger) LevelHandler() http.Handler {
+	return &levelHandler{logger: l, reverts: make(map[string]*pendingRevert)}
+}
+
+// ServeHTTP serves the GET and PUT requests.
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.respond(w, http.StatusOK)
+	case http.MethodPut:
+		var req levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.apply(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.respond(w, http.StatusOK)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// apply applies a level change, scheduling its revert when it has a TTL. A change without a TTL cancels the pending
+// revert of the same target.
+func (h *levelHandler) apply(req levelRequest) error {
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ttl %q", req.TTL)
+		}
+	}
+	target, set, current := h.target(req)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	original := current()
+	if err := set(req.Level); err != nil {
+		return err
+	}
+	if pending, ok := h.reverts[target]; ok {
+		pending.timer.Stop()
+		original = pending.original
+		delete(h.reverts, target)
+	}
+	if ttl == 0 {
+		return nil
+	}
+	pending := &pendingRevert{at: time.Now().Add(ttl), original: original}
+	pending.timer = time.AfterFunc(ttl, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.reverts[target] != pending {
+			return
+		}
+		delete(h.reverts, target)
+		_ = set(original)
+	})
+	h.reverts[target] = pending
+	return nil
+}
+
+// target returns the name of the target of a request, the function setting its level and the function returning its
+// current level.
+func (h *levelHandler) target(req levelRequest) (string, func(string) error, func() string) {
+	switch {
+	case req.Package != "":
+		return "package:" + req.Package,
+			func(level string) error { return SetPackageLevel(req.Package, level) },
+			func() string { return PackageLevels()[req.Package] }
+	case req.App != "":
+		return "app:" + req.App,
+			func(level string) error { return SetAppLevel(req.App, level) },
+			func() string { return AppLevels()[req.App] }
+	default:
+		return "logger", h.logger.SetLevel, func() string { return h.logger.GetLevel().String() }
+	}
+}
+
+// respond writes the current levels and pending reverts as JSON.
+func (h *levelHandler) respond(w http.ResponseWriter, status int) {
+	resp := levelResponse{
+		Level:    h.logger.GetLevel().String(),
+		Apps:     AppLevels(),
+		Packages: PackageLevels(),
+	}
+	h.mu.Lock()
+	if len(h.reverts) > 0 {
+		resp.Reverts = make(map[string]time.Time, len(h.reverts))
+		for target, pending := range h.reverts {
+			resp.Reverts[target] = pending.at
+		}
+	}
+	h.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
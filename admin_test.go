@@ -0,0 +1,112 @@
+package zlogs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// serveLevel sends a request to the level handler and decodes its response.
+func serveLevel(t *testing.T, h http.Handler, method, body string) (int, levelResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "/log/level", strings.NewReader(body)))
+	var resp levelResponse
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, resp
+}
+
+// TestLevelHandler verifies the GET and PUT of the level of the Logger and of the overrides.
+func TestLevelHandler(t *testing.T) {
+	resetOverrides(t)
+	logger := New(&Config{Level: "info"})
+	h := logger.LevelHandler()
+
+	if code, resp := serveLevel(t, h, http.MethodGet, ""); code != http.StatusOK || resp.Level != "info" {
+		t.Errorf("got %d %+v", code, resp)
+	}
+	if code, resp := serveLevel(t, h, http.MethodPut, `{"level":"debug"}`); code != http.StatusOK || resp.Level != "debug" {
+		t.Errorf("got %d %+v", code, resp)
+	}
+	if logger.GetLevel() != zerolog.DebugLevel {
+		t.Errorf("expected the level to change, got %v", logger.GetLevel())
+	}
+	code, resp := serveLevel(t, h, http.MethodPut, `{"level":"trace","app":"orders"}`)
+	if code != http.StatusOK || resp.Apps["orders"] != "trace" {
+		t.Errorf("got %d %+v", code, resp)
+	}
+	code, resp = serveLevel(t, h, http.MethodPut, `{"level":"warn","package":"github.com/acme/orders"}`)
+	if code != http.StatusOK || resp.Packages["github.com/acme/orders"] != "warn" {
+		t.Errorf("got %d %+v", code, resp)
+	}
+}
+
+// TestLevelHandlerErrors verifies the rejection of invalid requests.
+func TestLevelHandlerErrors(t *testing.T) {
+	h := New(&Config{Level: "info"}).LevelHandler()
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"InvalidBody", http.MethodPut, `{`, http.StatusBadRequest},
+		{"InvalidLevel", http.MethodPut, `{"level":"verbose"}`, http.StatusBadRequest},
+		{"EmptyLoggerLevel", http.MethodPut, `{"level":""}`, http.StatusBadRequest},
+		{"InvalidTTL", http.MethodPut, `{"level":"debug","ttl":"soon"}`, http.StatusBadRequest},
+		{"Method", http.MethodPost, `{"level":"debug"}`, http.StatusMethodNotAllowed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if code, _ := serveLevel(t, h, tc.method, tc.body); code != tc.want {
+				t.Errorf("got %d, want %d", code, tc.want)
+			}
+		})
+	}
+}
+
+// TestLevelHandlerTTL verifies that a change with a TTL reverts to the level before the first pending change.
+func TestLevelHandlerTTL(t *testing.T) {
+	logger := New(&Config{Level: "info"})
+	h := logger.LevelHandler()
+
+	serveLevel(t, h, http.MethodPut, `{"level":"debug","ttl":"1h"}`)
+	_, resp := serveLevel(t, h, http.MethodPut, `{"level":"trace","ttl":"20ms"}`)
+	if _, ok := resp.Reverts["logger"]; !ok {
+		t.Errorf("expected a pending revert, got %+v", resp)
+	}
+	deadline := time.Now().Add(time.Second)
+	for logger.GetLevel() != zerolog.InfoLevel {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the level to revert to info, got %v", logger.GetLevel())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, resp := serveLevel(t, h, http.MethodGet, ""); len(resp.Reverts) != 0 {
+		t.Errorf("expected no pending revert, got %+v", resp.Reverts)
+	}
+}
+
+// TestLevelHandlerInvalidLevelKeepsRevert verifies that a rejected change leaves the pending revert in place.
+func TestLevelHandlerInvalidLevelKeepsRevert(t *testing.T) {
+	logger := New(&Config{Level: "info"})
+	h := logger.LevelHandler()
+
+	serveLevel(t, h, http.MethodPut, `{"level":"debug","ttl":"1h"}`)
+	if code, _ := serveLevel(t, h, http.MethodPut, `{"level":"verbose"}`); code != http.StatusBadRequest {
+		t.Errorf("got %d, want %d", code, http.StatusBadRequest)
+	}
+	pending, ok := h.(*levelHandler).reverts["logger"]
+	if !ok || pending.original != "info" || logger.GetLevel() != zerolog.DebugLevel {
+		t.Errorf("expected the pending revert to info kept, got %+v at %v", pending, logger.GetLevel())
+	}
+}
@@ -0,0 +1,267 @@
+package zlogs
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// defaultAsyncBufferSize is the number of entries buffered by an AsyncWriter when AsyncConfig.BufferSize is not set.
+const defaultAsyncBufferSize = 1024
+
+// defaultReportInterval is the period at which an AsyncWriter reports its dropped entries when not configured.
+const defaultReportInterval = 10 * time.Second
+
+// OverflowPolicy decides what an AsyncWriter does with an entry when its buffer is full.
+type OverflowPolicy string
+
+// OverflowBlock waits for room in the buffer, so that no entry is lost.
+// OverflowDropNewest drops the incoming entry.
+// OverflowDropOldest drops the oldest buffered entry to make room for the incoming one.
+// OverflowDropBelowLevel drops the incoming entry when its level is below AsyncConfig.DropLevel, and waits otherwise.
+const (
+	OverflowBlock          OverflowPolicy = "block"
+	OverflowDropNewest     OverflowPolicy = "drop_newest"
+	OverflowDropOldest     OverflowPolicy = "drop_oldest"
+	OverflowDropBelowLevel OverflowPolicy = "drop_below_level"
+)
+
+// AsyncConfig configures the asynchronous writing of the entries, so that a slow output does not stall the callers.
+type AsyncConfig struct {
+	Enabled bool
+	// BufferSize is the number of entries waiting to be written, 1024 by default.
+	BufferSize int
+	// Overflow is the policy applied when the buffer is full, OverflowBlock by default.
+	Overflow OverflowPolicy
+	// DropLevel is the level under which OverflowDropBelowLevel drops the entries, "warn" by default.
+	DropLevel string
+	// ReportInterval is the period at which the number of dropped entries is logged, 10 seconds by default.
+	ReportInterval time.Duration
+}
+
+// asyncEntry is a buffered entry and its level.
+type asyncEntry struct {
+	level zerolog.Level
+	p     []byte
+}
+
+// AsyncWriter is a zerolog.LevelWriter buffering the entries in a bounded ring buffer and writing them to the
+// underlying writer from a background goroutine. Flush waits for the buffered entries to be written and Close
+// stops the goroutine; the entries written after Close go straight to the underlying writer.
+type AsyncWriter struct {
+	out            io.Writer
+	overflow       OverflowPolicy
+	dropLevel      zerolog.Level
+	reportInterval time.Duration
+	// report logs the number of entries dropped since the previous report.
+	report func(dropped uint64)
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	entries []asyncEntry
+	head    int
+	count   int
+	writing bool
+	closed  bool
+	waiters []chan struct{}
+
+	outMu    sync.Mutex
+	dropped  atomic.Uint64
+	reported uint64
+
+	wake      chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewAsyncWriter wraps out with an AsyncWriter configured by config, whose Enabled flag is ignored.
+func NewAsyncWriter(out io.Writer, config AsyncConfig) *AsyncWriter {
+	size := config.BufferSize
+	if size <= 0 {
+		size = defaultAsyncBufferSize
+	}
+	overflow := config.Overflow
+	if overflow == "" {
+		overflow = OverflowBlock
+	}
+	dropLevel, err := zerolog.ParseLevel(config.DropLevel)
+	if err != nil || config.DropLevel == "" {
+		dropLevel = zerolog.WarnLevel
+	}
+	interval := config.ReportInterval
+	if interval <= 0 {
+		interval = defaultReportInterval
+	}
+	w := &AsyncWriter{
+		out:            out,
+		overflow:       overflow,
+		dropLevel:      dropLevel,
+		reportInterval: interval,
+		entries:        make([]asyncEntry, size),
+		wake:           make(chan struct{}, 1),
+		done:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// Write buffers p without a level.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel buffers a copy of p, applying the overflow policy when the buffer is full.
+func (w *AsyncWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	w.mu.Lock()
+	for !w.closed && w.count == len(w.entries) {
+		if w.overflow == OverflowDropNewest || (w.overflow == OverflowDropBelowLevel && level < w.dropLevel) {
+			w.mu.Unlock()
+			w.dropped.Add(1)
+			return len(p), nil
+		}
+		if w.overflow == OverflowDropOldest {
+			w.head = (w.head + 1) % len(w.entries)
+			w.count--
+			w.dropped.Add(1)
+			break
+		}
+		w.notFull.Wait()
+	}
+	if w.closed {
+		w.mu.Unlock()
+		if err := w.write(asyncEntry{level: level, p: p}); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	w.entries[(w.head+w.count)%len(w.entries)] = asyncEntry{level: level, p: append([]byte(nil), p...)}
+	w.count++
+	w.mu.Unlock()
+	w.signal()
+	return len(p), nil
+}
+
+// Dropped returns the number of entries dropped since the creation of the writer.
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Flush waits until every buffered entry is written, or until the context is done.
+func (w *AsyncWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	if w.count == 0 && !w.writing {
+		w.mu.Unlock()
+		return nil
+	}
+	idle := make(chan struct{})
+	w.waiters = append(w.waiters, idle)
+	w.mu.Unlock()
+	w.signal()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes the buffered entries and stops the background goroutine. It does not close the underlying writer.
+func (w *AsyncWriter) Close() error {
+	w.closeOnce.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+		close(w.done)
+	})
+	<-w.stopped
+	return nil
+}
+
+// signal wakes up the background goroutine without blocking.
+func (w *AsyncWriter) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run writes the buffered entries and reports the dropped ones until the writer is closed.
+func (w *AsyncWriter) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.reportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.wake:
+			w.drain()
+		case <-ticker.C:
+			w.reportDropped()
+		case <-w.done:
+			w.drain()
+			w.reportDropped()
+			return
+		}
+	}
+}
+
+// drain writes the buffered entries until the buffer is empty, then releases the Flush callers.
+func (w *AsyncWriter) drain() {
+	for {
+		w.mu.Lock()
+		if w.count == 0 {
+			w.writing = false
+			waiters := w.waiters
+			w.waiters = nil
+			w.mu.Unlock()
+			for _, idle := range waiters {
+				close(idle)
+			}
+			return
+		}
+		batch := make([]asyncEntry, w.count)
+		for i := range batch {
+			batch[i] = w.entries[(w.head+i)%len(w.entries)]
+			w.entries[(w.head+i)%len(w.entries)] = asyncEntry{}
+		}
+		w.head, w.count, w.writing = 0, 0, true
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		for _, entry := range batch {
+			_ = w.write(entry)
+		}
+	}
+}
+
+// write writes an entry to the underlying writer, keeping its level for a zerolog.LevelWriter.
+func (w *AsyncWriter) write(entry asyncEntry) error {
+	w.outMu.Lock()
+	defer w.outMu.Unlock()
+	if lw, ok := w.out.(zerolog.LevelWriter); ok {
+		_, err := lw.WriteLevel(entry.level, entry.p)
+		return err
+	}
+	_, err := w.out.Write(entry.p)
+	return err
+}
+
+// reportDropped reports the entries dropped since the previous report, if any.
+func (w *AsyncWriter) reportDropped() {
+	dropped := w.dropped.Load()
+	if dropped == w.reported || w.report == nil {
+		return
+	}
+	w.outMu.Lock()
+	w.report(dropped - w.reported)
+	w.outMu.Unlock()
+	w.reported = dropped
+}
@@ -0,0 +1,223 @@
+package zlogs
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// gatedWriter is a writer blocking until its gate is opened, to simulate a slow output.
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{gate: make(chan struct{})}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// fillAsyncWriter writes a first entry that blocks the background goroutine on the gate, then fills the buffer.
+func fillAsyncWriter(t *testing.T, w *AsyncWriter, entries ...string) {
+	t.Helper()
+	_, _ = w.Write([]byte("in-flight\n"))
+	deadline := time.Now().Add(time.Second)
+	for {
+		w.mu.Lock()
+		writing := w.writing
+		w.mu.Unlock()
+		if writing {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the in-flight entry was not picked up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for _, entry := range entries {
+		_, _ = w.Write([]byte(entry + "\n"))
+	}
+}
+
+// TestAsyncWriterFlush verifies that Flush waits for the buffered entries, in order.
+func TestAsyncWriterFlush(t *testing.T) {
+	out := newGatedWriter()
+	close(out.gate)
+	w := NewAsyncWriter(out, AsyncConfig{BufferSize: 4})
+	defer w.Close()
+	for _, entry := range []string{"a\n", "b\n", "c\n", "d\n", "e\n", "f\n"} {
+		_, _ = w.Write([]byte(entry))
+	}
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "a\nb\nc\nd\ne\nf\n" {
+		t.Errorf("got %q", got)
+	}
+	if w.Dropped() != 0 {
+		t.Errorf("expected no dropped entry with the block policy, got %d", w.Dropped())
+	}
+}
+
+// TestAsyncWriterFlushTimeout verifies that Flush gives up when its context is done.
+func TestAsyncWriterFlushTimeout(t *testing.T) {
+	out := newGatedWriter()
+	w := NewAsyncWriter(out, AsyncConfig{BufferSize: 4})
+	_, _ = w.Write([]byte("blocked\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	close(out.gate)
+	_ = w.Close()
+	if got := out.String(); got != "blocked\n" {
+		t.Errorf("expected the entry written on Close, got %q", got)
+	}
+}
+
+// TestAsyncWriterOverflow verifies the entries kept by each overflow policy when the buffer is full.
+func TestAsyncWriterOverflow(t *testing.T) {
+	cases := []struct {
+		policy  OverflowPolicy
+		want    string
+		dropped uint64
+	}{
+		{OverflowDropNewest, "in-flight\na\nb\n", 2},
+		{OverflowDropOldest, "in-flight\nc\nd\n", 2},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			out := newGatedWriter()
+			w := NewAsyncWriter(out, AsyncConfig{BufferSize: 2, Overflow: tc.policy})
+			fillAsyncWriter(t, w, "a", "b", "c", "d")
+			close(out.gate)
+			_ = w.Close()
+
+			if got := out.String(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			if w.Dropped() != tc.dropped {
+				t.Errorf("got %d dropped entries, want %d", w.Dropped(), tc.dropped)
+			}
+		})
+	}
+}
+
+// TestAsyncWriterDropBelowLevel verifies that only the entries below the drop level are dropped when full.
+func TestAsyncWriterDropBelowLevel(t *testing.T) {
+	out := newGatedWriter()
+	w := NewAsyncWriter(out, AsyncConfig{BufferSize: 1, Overflow: OverflowDropBelowLevel, DropLevel: "warn"})
+	fillAsyncWriter(t, w, "a")
+	_, _ = w.WriteLevel(zerolog.InfoLevel, []byte("info\n"))
+
+	written := make(chan struct{})
+	go func() {
+		_, _ = w.WriteLevel(zerolog.ErrorLevel, []byte("error\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("expected the error entry to wait for room in the buffer")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(out.gate)
+	<-written
+	_ = w.Close()
+
+	if got := out.String(); got != "in-flight\na\nerror\n" {
+		t.Errorf("got %q", got)
+	}
+	if w.Dropped() != 1 {
+		t.Errorf("expected the info entry dropped, got %d", w.Dropped())
+	}
+}
+
+// TestAsyncWriterReport verifies that the dropped entries are reported once.
+func TestAsyncWriterReport(t *testing.T) {
+	out := newGatedWriter()
+	w := NewAsyncWriter(out, AsyncConfig{BufferSize: 1, Overflow: OverflowDropNewest})
+	var reports []uint64
+	w.report = func(dropped uint64) { reports = append(reports, dropped) }
+	fillAsyncWriter(t, w, "a", "b", "c")
+	close(out.gate)
+	_ = w.Close()
+
+	if len(reports) != 1 || reports[0] != 2 {
+		t.Errorf("expected a single report of 2 dropped entries, got %v", reports)
+	}
+}
+
+// TestAsyncWriterAfterClose verifies that the entries written after Close go straight to the underlying writer.
+func TestAsyncWriterAfterClose(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewAsyncWriter(&buf, AsyncConfig{})
+	_ = w.Close()
+	_, _ = w.Write([]byte("late\n"))
+	if got := buf.String(); got != "late\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// TestLoggerAsync verifies that an asynchronous Logger writes its entries on Flush and reports its dropped entries.
+func TestLoggerAsync(t *testing.T) {
+	out := newGatedWriter()
+	logger := New(&Config{
+		Level:   "debug",
+		Outputs: []OutputConfig{{Writer: out}},
+		Async:   AsyncConfig{Enabled: true, BufferSize: 1, Overflow: OverflowDropNewest},
+	})
+	fillAsyncWriter(t, logger.output.async, "a", "b")
+	close(out.gate)
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	logger.Event(logger.Info()).Msg("after flush")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.String()
+	if !strings.Contains(got, "after flush") {
+		t.Errorf("expected the entry written on Close, got %q", got)
+	}
+	if !strings.Contains(got, `"dropped_entries":1`) {
+		t.Errorf("expected the dropped entries reported, got %q", got)
+	}
+}
+
+// TestLoggerCloseFiles verifies that Close closes the files opened for the outputs.
+func TestLoggerCloseFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger := New(&Config{Level: "debug", Outputs: []OutputConfig{{Path: path}, {Path: OutputStdout}}})
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.output.closers) != 1 {
+		t.Fatalf("expected only the file to be closed, got %v", logger.output.closers)
+	}
+	if _, err := logger.output.closers[0].(*os.File).Write([]byte("x")); err == nil {
+		t.Error("expected the file to be closed")
+	}
+}
@@ -0,0 +1,124 @@
+package zlogs
+
+import (
+	"sort"
+	"sync"
+)
+
+// maskedFieldsKey is the field listing, in dry-run mode, the fields that would have been masked.
+const maskedFieldsKey = "masked_fields"
+
+// MaskingObserver is notified of every field and detected value masked by a Logger or a MaskingWriter,
+// or that would have been masked in dry-run mode.
+type MaskingObserver interface {
+	// FieldMasked receives the rule that matched the field: its lower-cased key name or the path selector.
+	FieldMasked(rule string)
+	// ValueDetected receives the name of the detector that found a value.
+	ValueDetected(detector string)
+}
+
+// MaskingCounter is a MaskingObserver counting the masked fields per rule and the detected values per detector.
+// It is safe for concurrent use.
+type MaskingCounter struct {
+	mu        sync.Mutex
+	fields    map[string]uint64
+	detectors map[string]uint64
+}
+
+// NewMaskingCounter creates an empty MaskingCounter.
+func NewMaskingCounter() *MaskingCounter {
+	return &MaskingCounter{
+		fields:    make(map[string]uint64),
+		detectors: make(map[string]uint64),
+	}
+}
+
+// FieldMasked counts a field masked by the given rule.
+func (c *MaskingCounter) FieldMasked(rule string) {
+	c.mu.Lock()
+	c.fields[rule]++
+	c.mu.Unlock()
+}
+
+// ValueDetected counts a value found by the given detector.
+func (c *MaskingCounter) ValueDetected(detector string) {
+	c.mu.Lock()
+	c.detectors[detector]++
+	c.mu.Unlock()
+}
+
+// Fields returns a snapshot of the number of masked fields per rule.
+func (c *MaskingCounter) Fields() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return copyCounts(c.fields)
+}
+
+// Detectors returns a snapshot of the number of detected values per detector.
+func (c *MaskingCounter) Detectors() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return copyCounts(c.detectors)
+}
+
+// Reset sets every counter back to zero.
+func (c *MaskingCounter) Reset() {
+	c.mu.Lock()
+	c.fields = make(map[string]uint64)
+	c.detectors = make(map[string]uint64)
+	c.mu.Unlock()
+}
+
+// copyCounts returns a copy of the counters.
+func copyCounts(counts map[string]uint64) map[string]uint64 {
+	snapshot := make(map[string]uint64, len(counts))
+	for key, count := range counts {
+		snapshot[key] = count
+	}
+	return snapshot
+}
+
+// maskTrace records the fields that would have been masked while logging a single entry in dry-run mode.
+type maskTrace struct {
+	mu     sync.Mutex
+	fields map[string]struct{}
+}
+
+// add records the path of a field, with the detector name for a detected value.
+func (t *maskTrace) add(field string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.fields == nil {
+		t.fields = make(map[string]struct{})
+	}
+	t.fields[field] = struct{}{}
+	t.mu.Unlock()
+}
+
+// list returns the recorded fields, sorted.
+func (t *maskTrace) list() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fields := make([]string, 0, len(t.fields))
+	for field := range t.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// forEntry returns the policy to apply to a single log entry: a copy recording the masked fields in dry-run mode,
+// the policy itself otherwise.
+func (p *maskingPolicy) forEntry() *maskingPolicy {
+	if !p.dryRun || !p.enabled {
+		return p
+	}
+	entry := *p
+	entry.trace = &maskTrace{}
+	return &entry
+}
@@ -0,0 +1,160 @@
+package zlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// TestMaskingCounter verifies that the observer counts the masked fields per rule and the detected values per detector.
+func TestMaskingCounter(t *testing.T) {
+	counter := NewMaskingCounter()
+	logger := New(&Config{
+		Level: "debug",
+		Masking: MaskingConfig{
+			Enabled:         true,
+			SensitiveFields: []string{"items[*].pin"},
+			Detectors:       []string{DetectorEmail},
+			Observer:        counter,
+		},
+	})
+
+	logger.maskFields(nil, map[string]interface{}{
+		"password": "a",
+		"data":     map[string]interface{}{"Password": "b", "note": "mail john@doe.com"},
+		"items":    []interface{}{map[string]interface{}{"pin": "1"}},
+	})
+	logger.maskMessage("contact jane@doe.com")
+
+	if want := map[string]uint64{"password": 2, "items[*].pin": 1}; !reflect.DeepEqual(counter.Fields(), want) {
+		t.Errorf("got fields %v, want %v", counter.Fields(), want)
+	}
+	if want := map[string]uint64{DetectorEmail: 2}; !reflect.DeepEqual(counter.Detectors(), want) {
+		t.Errorf("got detectors %v, want %v", counter.Detectors(), want)
+	}
+	counter.Reset()
+	if len(counter.Fields()) != 0 || len(counter.Detectors()) != 0 {
+		t.Error("expected counters to be reset")
+	}
+}
+
+// TestMaskingCounterDisabledEvent verifies that the fields of an entry filtered out by the level are not counted.
+func TestMaskingCounterDisabledEvent(t *testing.T) {
+	counter := NewMaskingCounter()
+	var buf bytes.Buffer
+	logger := New(&Config{Level: "info", Masking: MaskingConfig{Enabled: true, Observer: counter},
+		Outputs: []OutputConfig{{Writer: &buf}}})
+	logger.Event(logger.Debug()).
+		WithField("password", "a").
+		WithFields(map[string]interface{}{"password": "b"}).
+		Msgf("password=%s", "c")
+
+	if buf.Len() != 0 || len(counter.Fields()) != 0 || len(counter.Detectors()) != 0 {
+		t.Errorf("expected nothing written or counted, got %q and %v", buf.String(), counter.Fields())
+	}
+}
+
+// TestDryRun verifies that dry-run mode keeps the values and lists the fields that would have been masked.
+func TestDryRun(t *testing.T) {
+	config := MaskingConfig{
+		Enabled:   true,
+		Detectors: []string{DetectorEmail},
+		DryRun:    true,
+	}
+
+	t.Run("Event", func(t *testing.T) {
+		var buf bytes.Buffer
+		zl := zerolog.New(&buf)
+		logger := New(&Config{Level: "debug", Masking: config})
+		logger.Event(zl.Info()).
+			WithFields(map[string]interface{}{"data": map[string]interface{}{"password": "P@ss"}}).
+			WithField("contacts", []string{"john@doe.com"}).
+			Msg("password=P@ss")
+
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", buf.String(), err)
+		}
+		if entry["data"].(map[string]interface{})["password"] != "P@ss" || entry[zerolog.MessageFieldName] != "password=P@ss" {
+			t.Errorf("expected untouched values, got %v", entry)
+		}
+		want := []interface{}{"contacts[0]:email", "data.password", "password"}
+		if !reflect.DeepEqual(entry[maskedFieldsKey], want) {
+			t.Errorf("got %v, want %v", entry[maskedFieldsKey], want)
+		}
+	})
+
+	t.Run("EntriesAreIndependent", func(t *testing.T) {
+		var buf bytes.Buffer
+		zl := zerolog.New(&buf)
+		logger := New(&Config{Level: "debug", Masking: config})
+		logger.Event(zl.Info()).WithField("password", "a").Msg("")
+		logger.Event(zl.Info()).WithField("user", "jay").Msg("")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 || strings.Contains(lines[1], maskedFieldsKey) {
+			t.Errorf("expected masked_fields on the first entry only, got %q", lines)
+		}
+	})
+
+	t.Run("ContextFields", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(&Config{Level: "debug", Masking: config, Outputs: []OutputConfig{{Writer: &buf}}})
+		ctx := WithFields(context.Background(), map[string]interface{}{"password": "P@ss"})
+		logger.Event(logger.Info().Ctx(ctx)).WithField("user", "jay").Msg("hello")
+
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", buf.String(), err)
+		}
+		if entry["password"] != "P@ss" || strings.Count(buf.String(), `"password":`) != 1 {
+			t.Errorf("expected the context field once and untouched, got %q", buf.String())
+		}
+		if !reflect.DeepEqual(entry[maskedFieldsKey], []interface{}{"password"}) {
+			t.Errorf("got %v, want [password]", entry[maskedFieldsKey])
+		}
+	})
+
+	t.Run("Writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, _ = NewMaskingWriter(&buf, config).Write([]byte(`{"password":"P@ss","id":1}` + "\n"))
+		want := `{"password":"P@ss","id":1,"masked_fields":["password"]}` + "\n"
+		if got := buf.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("WriterKeepsMessageLast", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, _ = NewMaskingWriter(&buf, config).Write([]byte(`{"password":"P@ss","message":"hello"}` + "\n"))
+		want := `{"password":"P@ss","masked_fields":["password"],"message":"hello"}` + "\n"
+		if got := buf.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("AtOutputFieldNames", func(t *testing.T) {
+		var buf bytes.Buffer
+		atOutput := config
+		atOutput.AtOutput = true
+		logger := New(&Config{Level: "debug", Masking: atOutput, FieldNames: FieldNames{Message: "msg"},
+			Outputs: []OutputConfig{{Writer: &buf}}})
+		logger.Info().Str("password", "P@ss").Msg("hello")
+
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", buf.String(), err)
+		}
+		if entry["msg"] != "hello" || entry[zerolog.MessageFieldName] != nil {
+			t.Errorf("expected the message renamed, got %v", entry)
+		}
+		if !reflect.DeepEqual(entry[maskedFieldsKey], []interface{}{"password"}) {
+			t.Errorf("got %v, want [password]", entry[maskedFieldsKey])
+		}
+	})
+}
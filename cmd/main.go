@@ -10,9 +10,10 @@ func main() {
 
 	zlogs.NewLogger(&zlogs.Config{
 		Level: "debug",
-		Masking: zlogs.ConfigMasking{
+		Masking: zlogs.MaskingConfig{
 			Enabled:         true,
 			SensitiveFields: []string{"lastName"},
+			Detectors:       zlogs.DefaultDetectors,
 		},
 	})
 
@@ -24,10 +25,10 @@ func main() {
 			"firstname":     "John",
 			"lastName":      "Doe",
 		},
-		"credit_card": "4231234512341234",
+		"credit_card": "4111111111111111",
 	}
 
-	ctx := context.WithValue(context.Background(), zlogs.TraceID, "trace-id-value")
+	ctx := zlogs.WithTraceID(context.Background(), "trace-id-value")
 	event := zlogs.Info().WithFields(data).Ctx(ctx)
 	event2 := zlogs.Info().WithFields(data)
 	event.Msgf("hello world %s", "jay")
@@ -38,7 +39,7 @@ func main() {
 		}).Msg("hello world")
 	zlogs.NewGORMLogger(&zlogs.Config{
 		Level: "debug",
-		Masking: zlogs.ConfigMasking{
+		Masking: zlogs.MaskingConfig{
 			Enabled:         true,
 			SensitiveFields: []string{"lastName"},
 		},
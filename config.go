@@ -0,0 +1,307 @@
+package zlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultWatchInterval is the interval at which Watch checks the config file when none is given.
+const defaultWatchInterval = time.Second
+
+// errEmptyConfig is returned by readConfigFile for a YAML file without any document, which LoadConfig accepts as an
+// empty config but Watch rejects as a file being written.
+var errEmptyConfig = errors.New("empty config")
+
+// fileConfig is the part of a Config that can be read from a file or from the environment.
+type fileConfig struct {
+	AppName      string             `json:"app_name" yaml:"app_name"`
+	Level        string             `json:"level" yaml:"level"`
+	CallerEnable bool               `json:"caller_enable" yaml:"caller_enable"`
+	Format       Format             `json:"format" yaml:"format"`
+	Profile      Profile            `json:"profile" yaml:"profile"`
+	ProjectID    string             `json:"project_id" yaml:"project_id"`
+	FieldNames   fileFieldNames     `json:"field_names" yaml:"field_names"`
+	Outputs      []fileOutputConfig `json:"outputs" yaml:"outputs"`
+	Masking      fileMaskingConfig  `json:"masking" yaml:"masking"`
+	Sampling     fileSamplingConfig `json:"sampling" yaml:"sampling"`
+}
+
+// fileFieldNames is the FieldNames of a fileConfig.
+type fileFieldNames struct {
+	Timestamp string `json:"timestamp" yaml:"timestamp"`
+	Level     string `json:"level" yaml:"level"`
+	Message   string `json:"message" yaml:"message"`
+}
+
+// fileOutputConfig is an OutputConfig of a fileConfig.
+type fileOutputConfig struct {
+	Path  string `json:"path" yaml:"path"`
+	Level string `json:"level" yaml:"level"`
+}
+
+// fileMaskingConfig is the MaskingConfig of a fileConfig.
+type fileMaskingConfig struct {
+	Enabled    bool                    `json:"enabled" yaml:"enabled"`
+	Fields     []string                `json:"fields" yaml:"fields"`
+	Detectors  []string                `json:"detectors" yaml:"detectors"`
+	Patterns   map[string]string       `json:"patterns" yaml:"patterns"`
+	Strategies map[string]MaskStrategy `json:"strategies" yaml:"strategies"`
+	HashSecret string                  `json:"hash_secret" yaml:"hash_secret"`
+	AtOutput   bool                    `json:"at_output" yaml:"at_output"`
+	DryRun     bool                    `json:"dry_run" yaml:"dry_run"`
+}
+
+// fileSamplingConfig is the SamplingConfig of a fileConfig.
+type fileSamplingConfig struct {
+	Every uint32 `json:"every" yaml:"every"`
+	Level string `json:"level" yaml:"level"`
+}
+
+// envVariables maps the environment variables read by LoadConfig to the fileConfig setting they override.
+// List values are comma-separated.
+var envVariables = []struct {
+	name  string
+	apply func(c *fileConfig, value string) error
+}{
+	{"ZLOGS_APP_NAME", func(c *fileConfig, v string) error { c.AppName = v; return nil }},
+	{"ZLOGS_LEVEL", func(c *fileConfig, v string) error { c.Level = v; return nil }},
+	{"ZLOGS_CALLER_ENABLE", func(c *fileConfig, v string) error { return parseEnvBool(v, &c.CallerEnable) }},
+	{"ZLOGS_FORMAT", func(c *fileConfig, v string) error { c.Format = Format(v); return nil }},
+	{"ZLOGS_PROFILE", func(c *fileConfig, v string) error { c.Profile = Profile(v); return nil }},
+	{"ZLOGS_PROJECT_ID", func(c *fileConfig, v string) error { c.ProjectID = v; return nil }},
+	{"ZLOGS_MASKING_ENABLED", func(c *fileConfig, v string) error { return parseEnvBool(v, &c.Masking.Enabled) }},
+	{"ZLOGS_MASKING_FIELDS", func(c *fileConfig, v string) error { c.Masking.Fields = splitEnvList(v); return nil }},
+	{"ZLOGS_MASKING_DETECTORS", func(c *fileConfig, v string) error { c.Masking.Detectors = splitEnvList(v); return nil }},
+	{"ZLOGS_MASKING_HASH_SECRET", func(c *fileConfig, v string) error { c.Masking.HashSecret = v; return nil }},
+	{"ZLOGS_MASKING_AT_OUTPUT", func(c *fileConfig, v string) error { return parseEnvBool(v, &c.Masking.AtOutput) }},
+	{"ZLOGS_MASKING_DRY_RUN", func(c *fileConfig, v string) error { return parseEnvBool(v, &c.Masking.DryRun) }},
+	{"ZLOGS_SAMPLING_EVERY", func(c *fileConfig, v string) error {
+		every, err := strconv.ParseUint(v, 10, 32)
+		c.Sampling.Every = uint32(every)
+		return err
+	}},
+	{"ZLOGS_SAMPLING_LEVEL", func(c *fileConfig, v string) error { c.Sampling.Level = v; return nil }},
+}
+
+// LoadConfig builds a Config from the YAML (.yaml, .yml) or JSON (.json) file at path, when path is not empty, and
+// from the ZLOGS_* environment variables, which take precedence over the file: ZLOGS_APP_NAME, ZLOGS_LEVEL,
+// ZLOGS_CALLER_ENABLE, ZLOGS_FORMAT, ZLOGS_PROFILE, ZLOGS_PROJECT_ID, ZLOGS_MASKING_ENABLED, ZLOGS_MASKING_FIELDS,
+// ZLOGS_MASKING_DETECTORS, ZLOGS_MASKING_HASH_SECRET, ZLOGS_MASKING_AT_OUTPUT, ZLOGS_MASKING_DRY_RUN,
+// ZLOGS_SAMPLING_EVERY and ZLOGS_SAMPLING_LEVEL. Lists are comma-separated. Unknown keys of the file are errors.
+func LoadConfig(path string) (*Config, error) {
+	return loadConfig(path, true)
+}
+
+// loadConfig implements LoadConfig, rejecting an empty config file unless allowEmpty is set.
+func loadConfig(path string, allowEmpty bool) (*Config, error) {
+	var fc fileConfig
+	if path != "" {
+		if err := readConfigFile(path, &fc); err != nil && !(allowEmpty && errors.Is(err, errEmptyConfig)) {
+			return nil, err
+		}
+	}
+	for _, env := range envVariables {
+		value, ok := os.LookupEnv(env.name)
+		if !ok {
+			continue
+		}
+		if err := env.apply(&fc, value); err != nil {
+			return nil, fmt.Errorf("zlogs: invalid %s %q: %w", env.name, value, err)
+		}
+	}
+	return fc.config(), nil
+}
+
+// readConfigFile decodes the file at path into fc, by the format of its extension. It returns errEmptyConfig for a
+// YAML file without any document.
+func readConfigFile(path string, fc *fileConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("zlogs: cannot read config: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(fc)
+		if errors.Is(err, io.EOF) {
+			err = errEmptyConfig
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(fc)
+	default:
+		return fmt.Errorf("zlogs: unsupported config format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("zlogs: invalid config %s: %w", path, err)
+	}
+	return nil
+}
+
+// config converts the fileConfig into a Config.
+func (fc fileConfig) config() *Config {
+	config := &Config{
+		AppName:      fc.AppName,
+		Level:        fc.Level,
+		CallerEnable: fc.CallerEnable,
+		Format:       fc.Format,
+		Profile:      fc.Profile,
+		ProjectID:    fc.ProjectID,
+		FieldNames:   FieldNames(fc.FieldNames),
+		Masking: MaskingConfig{
+			Enabled:         fc.Masking.Enabled,
+			SensitiveFields: fc.Masking.Fields,
+			Detectors:       fc.Masking.Detectors,
+			Patterns:        fc.Masking.Patterns,
+			Strategies:      fc.Masking.Strategies,
+			HashSecret:      fc.Masking.HashSecret,
+			AtOutput:        fc.Masking.AtOutput,
+			DryRun:          fc.Masking.DryRun,
+		},
+		Sampling: SamplingConfig(fc.Sampling),
+	}
+	for _, output := range fc.Outputs {
+		config.Outputs = append(config.Outputs, OutputConfig{Path: output.Path, Level: output.Level})
+	}
+	return config
+}
+
+// Reload applies the level, masking and sampling of the config to the Logger while it is in use. The config is
+// validated first, so that an invalid one leaves the Logger unchanged. The other settings, such as the outputs and
+// whether masking happens at the output, stay the ones the Logger was created with, as do the TokenVault and
+// Observer when the config leaves them nil.
+func (l *Logger) Reload(config *Config) error {
+	level := zerolog.DebugLevel
+	if config.Level != "" {
+		var err error
+		if level, err = zerolog.ParseLevel(config.Level); err != nil {
+			return err
+		}
+	}
+	sampling, err := newSampler(config.Sampling)
+	if err != nil {
+		return err
+	}
+	masking := config.Masking
+	masking.AtOutput = l.output.masking != nil
+	if masking.TokenVault == nil {
+		masking.TokenVault = l.Masking.TokenVault
+	}
+	if masking.Observer == nil {
+		masking.Observer = l.Masking.Observer
+	}
+	if err := validateMasking(masking); err != nil {
+		return err
+	}
+
+	l.levels.state.Store(newLoggerState(level, sampling, masking))
+	return nil
+}
+
+// Watch reloads the Logger with LoadConfig(path) whenever the file at path changes, checking it every interval
+// (every second when interval is zero), until the context is done. A change is only applied once the size and the
+// modification time of the file are the same at two checks in a row, so that a file being written is not read.
+// An empty file, or a config that cannot be loaded or applied, is reported as an error entry and leaves the Logger
+// unchanged.
+func (l *Logger) Watch(ctx context.Context, path string, interval time.Duration) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("zlogs: cannot watch config: %w", err)
+	}
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var changed os.FileInfo
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current, err := os.Stat(path)
+			switch {
+			case err != nil || sameFileState(current, info):
+				changed = nil
+				continue
+			case changed == nil || !sameFileState(current, changed):
+				changed = current
+				continue
+			}
+			info, changed = current, nil
+			if err := l.reloadFile(path); err != nil {
+				l.Logger.Error().Err(err).Str("path", path).Msg("cannot reload the logger config")
+			}
+		}
+	}()
+	return nil
+}
+
+// reloadFile reloads the Logger from the config file at path, which must not be empty.
+func (l *Logger) reloadFile(path string) error {
+	config, err := loadConfig(path, false)
+	if err != nil {
+		return err
+	}
+	return l.Reload(config)
+}
+
+// sameFileState reports whether two states of a file have the same size and modification time.
+func sameFileState(a, b os.FileInfo) bool {
+	return a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+// validateMasking reports the detectors, patterns and strategies of the config that newMaskingPolicy would reject.
+func validateMasking(config MaskingConfig) error {
+	for _, name := range config.Detectors {
+		if _, ok := builtinDetectors[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("zlogs: unknown masking detector %q", name)
+		}
+	}
+	for name, pattern := range config.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("zlogs: invalid masking pattern %q: %w", name, err)
+		}
+	}
+	for _, strategy := range config.Strategies {
+		if _, err := parseMaskFunc(strategy, config.HashSecret, config.TokenVault); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseEnvBool parses a boolean environment variable into target.
+func parseEnvBool(value string, target *bool) error {
+	parsed, err := strconv.ParseBool(value)
+	*target = parsed
+	return err
+}
+
+// splitEnvList splits a comma-separated environment variable, dropping the empty items.
+func splitEnvList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
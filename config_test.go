@@ -0,0 +1,292 @@
+package zlogs
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// writeConfig writes a config file named name in a temporary directory and returns its path.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestLoadConfig verifies the YAML and JSON config files.
+func TestLoadConfig(t *testing.T) {
+	want := &Config{
+		AppName: "orders",
+		Level:   "info",
+		Format:  FormatLogfmt,
+		Outputs: []OutputConfig{{Path: "stderr", Level: "warn"}},
+		Masking: MaskingConfig{
+			Enabled:         true,
+			SensitiveFields: []string{"email", "items[*].card"},
+			Strategies:      map[string]MaskStrategy{"email": MaskEmail},
+		},
+		Sampling: SamplingConfig{Every: 10, Level: "debug"},
+	}
+	files := map[string]string{
+		"zlogs.yaml": `
+app_name: orders
+level: info
+format: logfmt
+outputs:
+  - path: stderr
+    level: warn
+masking:
+  enabled: true
+  fields: [email, "items[*].card"]
+  strategies:
+    email: email
+sampling:
+  every: 10
+  level: debug
+`,
+		"zlogs.json": `{
+	"app_name": "orders", "level": "info", "format": "logfmt",
+	"outputs": [{"path": "stderr", "level": "warn"}],
+	"masking": {"enabled": true, "fields": ["email", "items[*].card"], "strategies": {"email": "email"}},
+	"sampling": {"every": 10, "level": "debug"}
+}`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			config, err := LoadConfig(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, want) {
+				t.Errorf("got %+v, want %+v", config, want)
+			}
+		})
+	}
+}
+
+// TestLoadConfigEnv verifies that the environment variables take precedence over the file.
+func TestLoadConfigEnv(t *testing.T) {
+	path := writeConfig(t, "zlogs.yml", "level: info\nmasking:\n  fields: [email]\n")
+	t.Setenv("ZLOGS_LEVEL", "warn")
+	t.Setenv("ZLOGS_MASKING_ENABLED", "true")
+	t.Setenv("ZLOGS_MASKING_FIELDS", "email, phone,")
+	t.Setenv("ZLOGS_SAMPLING_EVERY", "5")
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Level != "warn" || !config.Masking.Enabled || config.Sampling.Every != 5 ||
+		!reflect.DeepEqual(config.Masking.SensitiveFields, []string{"email", "phone"}) {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	t.Setenv("ZLOGS_SAMPLING_EVERY", "often")
+	if _, err := LoadConfig(""); err == nil || !strings.Contains(err.Error(), "ZLOGS_SAMPLING_EVERY") {
+		t.Errorf("expected an error for the invalid variable, got %v", err)
+	}
+}
+
+// TestLoadConfigErrors verifies the rejection of the invalid config files.
+func TestLoadConfigErrors(t *testing.T) {
+	cases := map[string]string{
+		"unknown.yaml": "levle: info\n",
+		"unknown.json": `{"levle": "info"}`,
+		"invalid.json": `{"level": `,
+		"zlogs.toml":   `level = "info"`,
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadConfig(writeConfig(t, name, content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+// TestReload verifies that Reload changes the level, masking and sampling of a Logger, and that an invalid config
+// leaves it unchanged.
+func TestReload(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{Level: "info", Masking: MaskingConfig{Enabled: true}, Outputs: []OutputConfig{{Writer: &buf}}})
+	event := logger.Event(logger.Info())
+
+	err := logger.Reload(&Config{
+		Level:    "debug",
+		Masking:  MaskingConfig{Enabled: true, SensitiveFields: []string{"email"}},
+		Sampling: SamplingConfig{Every: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.WithField("email", "a@b.c").Msg("before")
+	if !strings.Contains(buf.String(), "a@b.c") {
+		t.Errorf("expected an event created before the reload to keep its policy, got %q", buf.String())
+	}
+	buf.Reset()
+	for i := 0; i < 4; i++ {
+		logger.Event(logger.Debug()).WithField("email", "a@b.c").Msg("after")
+	}
+	if got := buf.String(); strings.Count(got, "after") != 2 || strings.Contains(got, "a@b.c") {
+		t.Errorf("expected two sampled and masked entries, got %q", got)
+	}
+
+	invalid := []*Config{
+		{Level: "verbose"},
+		{Sampling: SamplingConfig{Every: 2, Level: "verbose"}},
+		{Masking: MaskingConfig{Detectors: []string{"unknown"}}},
+		{Masking: MaskingConfig{Patterns: map[string]string{"id": "("}}},
+		{Masking: MaskingConfig{Strategies: map[string]MaskStrategy{"id": MaskTokenize}}},
+	}
+	for _, config := range invalid {
+		if err := logger.Reload(config); err == nil {
+			t.Errorf("expected an error for %+v", config)
+		}
+	}
+	if logger.GetLevel() != zerolog.DebugLevel {
+		t.Errorf("expected the level to be unchanged, got %v", logger.GetLevel())
+	}
+}
+
+// TestReloadAtOutput verifies that Reload replaces the policy of the masking writer of the output.
+func TestReloadAtOutput(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{Masking: MaskingConfig{Enabled: true, AtOutput: true}, Outputs: []OutputConfig{{Writer: &buf}}})
+	if err := logger.Reload(&Config{Masking: MaskingConfig{Enabled: true, SensitiveFields: []string{"email"}}}); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info().Str("email", "a@b.c").Msg("through zerolog")
+	if strings.Contains(buf.String(), "a@b.c") {
+		t.Errorf("expected the output to mask the new field, got %q", buf.String())
+	}
+}
+
+// TestReloadSnapshot verifies that a Reload publishes the level, the sampling and the masking policy together, so that
+// they are never seen from two different configs.
+func TestReloadSnapshot(t *testing.T) {
+	configs := []*Config{
+		{Level: "info", Masking: MaskingConfig{Enabled: true, SensitiveFields: []string{"email"}}},
+		{Level: "debug", Sampling: SamplingConfig{Every: 2}, Masking: MaskingConfig{Enabled: true}},
+	}
+	logger := New(configs[0])
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			_ = logger.Reload(configs[i%2])
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		state := logger.levels.state.Load()
+		_, email := state.policy.match(nil, "email")
+		if (state.level == zerolog.InfoLevel) != email || (state.level == zerolog.InfoLevel) != (state.sampling == nil) {
+			t.Fatalf("got the level %v with sampling %v and email masked %v", state.level, state.sampling, email)
+		}
+	}
+}
+
+// TestWatch verifies that Watch reloads the Logger when the config file changes.
+func TestWatch(t *testing.T) {
+	var buf syncBuffer
+	path := writeConfig(t, "zlogs.yaml", "level: info\n")
+	logger := New(&Config{Level: "info", Outputs: []OutputConfig{{Writer: &buf}}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := logger.Watch(ctx, path, 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("level: error\nsampling:\n  every: 3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for logger.GetLevel() != zerolog.ErrorLevel {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the level to be reloaded, got %v", logger.GetLevel())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := os.WriteFile(path, []byte("level: verbose\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for !strings.Contains(buf.String(), "cannot reload the logger config") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the invalid config to be reported, got %q", buf.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if logger.GetLevel() != zerolog.ErrorLevel {
+		t.Errorf("expected the level to be unchanged, got %v", logger.GetLevel())
+	}
+	if err := logger.Watch(ctx, filepath.Join(t.TempDir(), "missing.yaml"), 0); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+// TestWatchEmptyFile verifies that a config file emptied while it is rewritten never disables masking.
+func TestWatchEmptyFile(t *testing.T) {
+	var buf syncBuffer
+	path := writeConfig(t, "zlogs.yaml", "masking:\n  enabled: true\n")
+	logger := New(&Config{Level: "info", Masking: MaskingConfig{Enabled: true}, Outputs: []OutputConfig{{Writer: &buf}}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := logger.Watch(ctx, path, 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "cannot reload the logger config") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the empty config to be reported, got %q", buf.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	logger.Event(logger.Info()).WithField("password", "P@ss").Msg("")
+	if strings.Contains(buf.String(), "P@ss") {
+		t.Errorf("expected masking to stay enabled, got %q", buf.String())
+	}
+	if _, err := LoadConfig(path); err != nil {
+		t.Errorf("expected LoadConfig to accept an empty file, got %v", err)
+	}
+}
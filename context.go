@@ -0,0 +1,103 @@
+package zlogs
+
+import (
+	"context"
+	"sort"
+
+	"github.com/rs/zerolog"
+)
+
+// Context keys of the fields and of the Logger carried by a context.
+const (
+	fieldsKey contextKey = iota + CallerSkip + 1
+	loggerKey
+)
+
+// contextAddedKey is the context key marking an entry whose context fields are already added by Event.send, and
+// noCallerKey the one marking an entry written without caller information, such as the access log.
+const (
+	contextAddedKey = spanContextKey + 1 + iota
+	noCallerKey
+)
+
+// WithTraceID returns a copy of the context carrying the trace ID logged as trace_id.
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, TraceID, id)
+}
+
+// WithRequestID returns a copy of the context carrying the request ID logged as request_id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, RequestID, id)
+}
+
+// WithCorrelationID returns a copy of the context carrying the correlation ID logged as correlation_id.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, CorrelationID, id)
+}
+
+// WithFields returns a copy of the context carrying the given fields in addition to the ones it already carries,
+// e.g. a user or tenant ID. They are added to every entry logged with the context, masked by the policy of the
+// Logger. The fields must not be modified afterwards.
+func WithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	merged := make(map[string]interface{}, len(fields))
+	for key, value := range contextFields(ctx) {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, fieldsKey, merged)
+}
+
+// NewContext returns a copy of the context carrying the Logger, returned by Ctx.
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// Ctx returns the Logger carried by the context, or the package-level logger, bound to the context so that its
+// entries carry the IDs and fields of the context.
+func Ctx(ctx context.Context) *Logger {
+	logger, ok := ctx.Value(loggerKey).(*Logger)
+	if !ok || logger == nil {
+		logger = std
+	}
+	bound := logger.Logger.With().Ctx(ctx).Logger()
+	scoped := *logger
+	scoped.Logger = &bound
+	return &scoped
+}
+
+// contextFields returns the fields carried by the context.
+func contextFields(ctx context.Context) map[string]interface{} {
+	fields, _ := ctx.Value(fieldsKey).(map[string]interface{})
+	return fields
+}
+
+// addContextFields adds the fields carried by the context to the event, sorted by key and masked with the policy
+// when it is enabled.
+func addContextFields(e *zerolog.Event, ctx context.Context, policy *maskingPolicy) {
+	fields := contextFields(ctx)
+	if len(fields) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		addMaskedField(e, key, fields[key], policy)
+	}
+}
+
+// addMaskedField adds a field of the context to the event, masked with the policy when it is enabled.
+func addMaskedField(e *zerolog.Event, key string, value interface{}, policy *maskingPolicy) {
+	if policy == nil || !policy.enabled {
+		e.Interface(key, value)
+		return
+	}
+	masked := (&Logger{policy: policy}).valueMasking(nil, make(map[string]interface{}, 1), key, value)
+	if value, keep := masked[key]; keep {
+		e.Interface(key, value)
+	}
+}
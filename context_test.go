@@ -0,0 +1,70 @@
+package zlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// TestContextHelpers verifies that the IDs and fields of a context are added to the entries of Ctx.
+func TestContextHelpers(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{AppName: "orders", Masking: MaskingConfig{Enabled: true}, Outputs: []OutputConfig{{Writer: &buf}}})
+
+	ctx := WithTraceID(context.Background(), "trace-1")
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithCorrelationID(ctx, "corr-1")
+	ctx = WithFields(ctx, map[string]interface{}{"tenant": "acme", "user_id": 1})
+	ctx = WithFields(ctx, map[string]interface{}{"user_id": 2, "password": "secret"})
+	ctx = NewContext(ctx, logger)
+
+	l := Ctx(ctx)
+	l.Event(l.Info()).Msg("from the context")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"appName": "orders", "trace_id": "trace-1", "request_id": "req-1", "correlation_id": "corr-1",
+		"tenant": "acme", "user_id": float64(2), "password": redactedValue,
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s: got %v, want %v", key, entry[key], value)
+		}
+	}
+	if l.Logger == logger.Logger {
+		t.Error("expected Ctx to bind a copy of the Logger")
+	}
+}
+
+// TestCtxDefault verifies that Ctx falls back to the package-level logger and leaves the fields of a parent context
+// untouched.
+func TestCtxDefault(t *testing.T) {
+	if Ctx(context.Background()).policy != std.policy {
+		t.Error("expected the package-level logger")
+	}
+	parent := WithFields(context.Background(), map[string]interface{}{"tenant": "acme"})
+	WithFields(parent, map[string]interface{}{"tenant": "other", "user_id": 1})
+	if fields := contextFields(parent); len(fields) != 1 || fields["tenant"] != "acme" {
+		t.Errorf("expected the parent fields untouched, got %v", fields)
+	}
+}
+
+// TestContextFieldsShared verifies that masking the fields of a context leaves its arrays untouched.
+func TestContextFieldsShared(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{Masking: MaskingConfig{Enabled: true}, Outputs: []OutputConfig{{Writer: &buf}}})
+	items := []interface{}{map[string]interface{}{"password": "secret"}}
+	ctx := WithFields(context.Background(), map[string]interface{}{"items": items})
+
+	logger.Info().Ctx(ctx).Msg("first")
+	if item := items[0].(map[string]interface{}); item["password"] != "secret" {
+		t.Errorf("expected the context fields untouched, got %v", item)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret")) {
+		t.Errorf("expected the fields masked, got %s", buf.String())
+	}
+}
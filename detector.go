@@ -0,0 +1,180 @@
+package zlogs
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DetectorPAN matches Luhn-valid payment card numbers.
+// DetectorEmail matches email addresses.
+// DetectorPhone matches E.164 and Thai mobile phone numbers.
+// DetectorThaiID matches Thai citizen IDs with a valid checksum.
+// DetectorIBAN matches IBANs with a valid mod-97 checksum.
+// DetectorToken matches JWTs and bearer tokens.
+const (
+	DetectorPAN    = "pan"
+	DetectorEmail  = "email"
+	DetectorPhone  = "phone"
+	DetectorThaiID = "thai_id"
+	DetectorIBAN   = "iban"
+	DetectorToken  = "token"
+)
+
+// DefaultDetectors lists every built-in detector, in the order they are applied.
+var DefaultDetectors = []string{DetectorToken, DetectorEmail, DetectorIBAN, DetectorThaiID, DetectorPAN, DetectorPhone}
+
+// valueDetector finds sensitive values inside a string by pattern and an optional validation of each match.
+type valueDetector struct {
+	name     string
+	pattern  *regexp.Regexp
+	validate func(match string) bool
+}
+
+// builtinDetectors maps the detector names to their implementation.
+var builtinDetectors = map[string]*valueDetector{
+	DetectorPAN: {
+		name:     DetectorPAN,
+		pattern:  regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		validate: isLuhnValid,
+	},
+	DetectorEmail: {
+		name:    DetectorEmail,
+		pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	},
+	DetectorPhone: {
+		name:    DetectorPhone,
+		pattern: regexp.MustCompile(`\+[1-9]\d{7,14}\b|\b0[689]\d[ -]?\d{3}[ -]?\d{4}\b`),
+	},
+	DetectorThaiID: {
+		name:     DetectorThaiID,
+		pattern:  regexp.MustCompile(`\b\d[ -]?\d{4}[ -]?\d{5}[ -]?\d{2}[ -]?\d\b`),
+		validate: isThaiIDValid,
+	},
+	DetectorIBAN: {
+		name:     DetectorIBAN,
+		pattern:  regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`),
+		validate: isIBANValid,
+	},
+	DetectorToken: {
+		name:    DetectorToken,
+		pattern: regexp.MustCompile(`(?i:\bbearer\s+[A-Za-z0-9._~+/\-]+=*)|\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`),
+	},
+}
+
+// newValueDetectors resolves the built-in detector names and compiles the custom patterns of the config.
+// Custom patterns are applied after the built-in detectors, sorted by name. It panics if a detector is unknown
+// or a pattern does not compile, so that a misconfigured masking never goes unnoticed.
+func newValueDetectors(config MaskingConfig) []*valueDetector {
+	detectors := make([]*valueDetector, 0, len(config.Detectors)+len(config.Patterns))
+	for _, name := range config.Detectors {
+		detector, ok := builtinDetectors[strings.ToLower(name)]
+		if !ok {
+			panic(fmt.Sprintf("zlogs: unknown masking detector %q", name))
+		}
+		detectors = append(detectors, detector)
+	}
+
+	names := make([]string, 0, len(config.Patterns))
+	for name := range config.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pattern, err := regexp.Compile(config.Patterns[name])
+		if err != nil {
+			panic(fmt.Sprintf("zlogs: invalid masking pattern %q: %v", name, err))
+		}
+		detectors = append(detectors, &valueDetector{name: name, pattern: pattern})
+	}
+	return detectors
+}
+
+// mask replaces every validated match of the detector in value using the given maskFunc.
+func (d *valueDetector) mask(value string, maskMatch maskFunc) string {
+	return d.pattern.ReplaceAllStringFunc(value, func(match string) string {
+		if d.validate != nil && !d.validate(match) {
+			return match
+		}
+		masked, _ := maskMatch(match)
+		return masked
+	})
+}
+
+// detect reports whether value holds at least one validated match of the detector.
+func (d *valueDetector) detect(value string) bool {
+	for _, match := range d.pattern.FindAllString(value, -1) {
+		if d.validate == nil || d.validate(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// digitsOnly strips the separators from a matched number.
+func digitsOnly(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+// isLuhnValid reports whether the digits of value pass the Luhn checksum.
+func isLuhnValid(value string) bool {
+	digits := digitsOnly(value)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isThaiIDValid reports whether value is a 13 digit Thai citizen ID with a valid check digit.
+func isThaiIDValid(value string) bool {
+	digits := digitsOnly(value)
+	if len(digits) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(digits[i]-'0') * (13 - i)
+	}
+	return (11-sum%11)%10 == int(digits[12]-'0')
+}
+
+// isIBANValid reports whether value passes the ISO 13616 mod-97 check.
+func isIBANValid(value string) bool {
+	iban := strings.ReplaceAll(value, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	var numeric strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			numeric.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			numeric.WriteString(fmt.Sprint(r - 'A' + 10))
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(numeric.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
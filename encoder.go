@@ -0,0 +1,437 @@
+package zlogs
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// structTagName is the struct tag controlling how a field is logged, e.g. `zlog:"mask"`, `zlog:"mask=last4"`,
+// `zlog:"omit"` or `zlog:"card_no,mask=last4"`.
+const structTagName = "zlog"
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	errorType           = reflect.TypeOf((*error)(nil)).Elem()
+	objectMarshalerType = reflect.TypeOf((*zerolog.LogObjectMarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonNumberType      = reflect.TypeOf(json.Number(""))
+	structFieldsCache   sync.Map
+	unmaskedPolicy      = &maskingPolicy{tagStrategies: &sync.Map{}}
+)
+
+// cycleValue replaces a struct, a map or a slice found again inside itself, e.g. through a pointer to its parent.
+const cycleValue = "<cycle>"
+
+// visit identifies a struct, a map or a slice being walked by its address and its type, as a struct shares its
+// address with its first field.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// visits are the structs, maps and slices enclosing the value being walked.
+type visits []visit
+
+// enter returns the visits with v added. It returns false when v is already being walked, i.e. it references itself.
+// The values that cannot be part of a cycle, such as the structs passed by value, are not recorded.
+func (s visits) enter(v reflect.Value) (visits, bool) {
+	var ptr uintptr
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		ptr = v.Pointer()
+	case reflect.Struct, reflect.Array:
+		if v.CanAddr() {
+			ptr = v.UnsafeAddr()
+		}
+	}
+	if ptr == 0 {
+		return s, true
+	}
+	for _, seen := range s {
+		if seen.ptr == ptr && seen.typ == v.Type() {
+			return s, false
+		}
+	}
+	return append(s[:len(s):len(s)], visit{ptr: ptr, typ: v.Type()}), true
+}
+
+// structField is the cached logging information of an exported struct field.
+type structField struct {
+	index     int
+	name      string
+	omitEmpty bool
+	mask      bool
+	strategy  MaskStrategy
+}
+
+// cachedStructFields returns the loggable fields of a struct type, parsing its tags once per type.
+func cachedStructFields(t reflect.Type) []structField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		field := structField{index: i, name: sf.Name}
+		if jsonTag, ok := sf.Tag.Lookup("json"); ok {
+			name, opts, _ := strings.Cut(jsonTag, ",")
+			if name != "" && name != "-" {
+				field.name = name
+			}
+			field.omitEmpty = strings.Contains(opts, "omitempty")
+		}
+		if omit := parseStructTag(sf.Tag.Get(structTagName), &field); omit {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	actual, _ := structFieldsCache.LoadOrStore(t, fields)
+	return actual.([]structField)
+}
+
+// parseStructTag applies the options of a zlog tag to the field. It returns true when the field must be omitted.
+func parseStructTag(tag string, field *structField) bool {
+	if tag == "" {
+		return false
+	}
+	for i, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "omit" || opt == "-":
+			return true
+		case opt == "mask":
+			field.mask = true
+		case strings.HasPrefix(opt, "mask="):
+			field.mask = true
+			field.strategy = MaskStrategy(strings.TrimPrefix(opt, "mask="))
+		case i == 0 && opt != "":
+			field.name = opt
+		}
+	}
+	return false
+}
+
+// maskFuncFor returns the maskFunc of a strategy given in a struct tag, caching it on the policy.
+// An invalid strategy falls back to redaction, as a tag is only read while logging.
+func (p *maskingPolicy) maskFuncFor(strategy MaskStrategy) maskFunc {
+	if mask, ok := p.tagStrategies.Load(strategy); ok {
+		return mask.(maskFunc)
+	}
+	parsed, err := parseMaskFunc(strategy, p.hashSecret, p.vault)
+	if err != nil {
+		parsed = redact
+	}
+	mask, _ := p.tagStrategies.LoadOrStore(strategy, parsed)
+	return mask.(maskFunc)
+}
+
+// encodeField writes a field found under the parent path, inside the seen values, into the event, masking it when it
+// is sensitive.
+func (p *maskingPolicy) encodeField(e *zerolog.Event, parent fieldPath, key string, v reflect.Value, seen visits) {
+	if mask, sensitive := p.match(parent, key); sensitive {
+		if masked, keep := maskValue(mask, interfaceOf(v)); keep {
+			e.Interface(key, masked)
+		}
+		return
+	}
+	p.encodeValue(e, p.child(parent, key), key, v, seen)
+}
+
+// encodeValue writes a value located at path, inside the seen values, into the event by its kind, recursing into
+// structs, maps and slices. A value found inside itself is written as cycleValue.
+func (p *maskingPolicy) encodeValue(e *zerolog.Event, path fieldPath, key string, v reflect.Value, seen visits) {
+	v = indirect(v)
+	if !v.IsValid() {
+		e.Interface(key, nil)
+		return
+	}
+	inner, ok := seen.enter(v)
+	if !ok {
+		e.Str(key, cycleValue)
+		return
+	}
+	if p.encodeSpecial(e, path, key, v) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		e.Str(key, p.maskDetected(path, v.String()))
+	case reflect.Bool:
+		e.Bool(key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.Int64(key, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.Uint64(key, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.Float64(key, v.Float())
+	case reflect.Struct:
+		e.Object(key, structObject{policy: p, path: path, value: v, seen: inner})
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			e.Interface(key, p.plainValue(path, v, seen))
+			return
+		}
+		e.Object(key, mapObject{policy: p, path: path, value: v, seen: inner})
+	case reflect.Slice, reflect.Array:
+		if (v.Kind() == reflect.Slice && v.IsNil()) || v.Type().Elem().Kind() == reflect.Uint8 {
+			e.Interface(key, v.Interface())
+			return
+		}
+		e.Array(key, sliceArray{policy: p, path: path, value: v, seen: inner})
+	default:
+		e.Interface(key, v.Interface())
+	}
+}
+
+// encodeSpecial writes the values whose types define their own representation. It returns false for other types.
+func (p *maskingPolicy) encodeSpecial(e *zerolog.Event, path fieldPath, key string, v reflect.Value) bool {
+	if v.Type() == timeType {
+		e.Time(key, v.Interface().(time.Time))
+		return true
+	}
+	m := withMethods(v)
+	t := m.Type()
+	switch {
+	case t.Implements(objectMarshalerType):
+		e.Object(key, m.Interface().(zerolog.LogObjectMarshaler))
+	case t.Implements(errorType):
+		e.Str(key, p.maskDetected(path, m.Interface().(error).Error()))
+	case t.Implements(jsonMarshalerType):
+		e.Interface(key, m.Interface())
+	case t.Implements(textMarshalerType):
+		text, err := m.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			e.Str(key, err.Error())
+			return true
+		}
+		e.Str(key, p.maskDetected(path, string(text)))
+	default:
+		return false
+	}
+	return true
+}
+
+// encodeElem appends a value located at path, inside the seen values, to the array, recursing into structs, maps and
+// slices. A value found inside itself is appended as cycleValue.
+func (p *maskingPolicy) encodeElem(a *zerolog.Array, path fieldPath, v reflect.Value, seen visits) {
+	v = indirect(v)
+	if !v.IsValid() {
+		a.Interface(nil)
+		return
+	}
+	inner, ok := seen.enter(v)
+	switch {
+	case !ok:
+		a.Str(cycleValue)
+	case v.Kind() == reflect.String && !withMethods(v).Type().Implements(textMarshalerType):
+		a.Str(p.maskDetected(path, v.String()))
+	case v.Kind() == reflect.Struct && !hasOwnEncoding(v):
+		a.Object(structObject{policy: p, path: path, value: v, seen: inner})
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		a.Object(mapObject{policy: p, path: path, value: v, seen: inner})
+	default:
+		a.Interface(p.plainValue(path, v, seen))
+	}
+}
+
+// maskDetected applies the value detectors to a string located at path when masking is enabled.
+func (p *maskingPolicy) maskDetected(path fieldPath, value string) string {
+	if !p.enabled {
+		return value
+	}
+	return p.maskString(path, value)
+}
+
+// structObject writes a struct, inside the seen values, into a zerolog event field by field.
+type structObject struct {
+	policy *maskingPolicy
+	path   fieldPath
+	value  reflect.Value
+	seen   visits
+}
+
+// MarshalZerologObject writes the loggable fields of the struct, applying their zlog tags.
+func (o structObject) MarshalZerologObject(e *zerolog.Event) {
+	for _, field := range cachedStructFields(o.value.Type()) {
+		fv := o.value.Field(field.index)
+		if field.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if field.mask && o.policy.tagsEnabled {
+			if masked, keep := o.policy.maskFuncFor(field.strategy)(fmt.Sprint(interfaceOf(fv))); keep {
+				e.Str(field.name, masked)
+			}
+			continue
+		}
+		o.policy.encodeField(e, o.path, field.name, fv, o.seen)
+	}
+}
+
+// mapObject writes a map with string keys, inside the seen values, into a zerolog event entry by entry.
+type mapObject struct {
+	policy *maskingPolicy
+	path   fieldPath
+	value  reflect.Value
+	seen   visits
+}
+
+// MarshalZerologObject writes every entry of the map, masking the sensitive keys.
+func (o mapObject) MarshalZerologObject(e *zerolog.Event) {
+	iter := o.value.MapRange()
+	for iter.Next() {
+		o.policy.encodeField(e, o.path, iter.Key().String(), iter.Value(), o.seen)
+	}
+}
+
+// sliceArray writes a slice or an array, inside the seen values, into a zerolog array element by element.
+type sliceArray struct {
+	policy *maskingPolicy
+	path   fieldPath
+	value  reflect.Value
+	seen   visits
+}
+
+// MarshalZerologArray appends every element of the slice.
+func (s sliceArray) MarshalZerologArray(a *zerolog.Array) {
+	for i := 0; i < s.value.Len(); i++ {
+		s.policy.encodeElem(a, s.policy.childIndex(s.path, i), s.value.Index(i), s.seen)
+	}
+}
+
+// plainValue converts a value located at path, inside the seen values, into maps, slices and primitives with the
+// masking policy applied, for the shapes that cannot be written into zerolog directly. A value found inside itself is
+// replaced by cycleValue.
+func (p *maskingPolicy) plainValue(path fieldPath, v reflect.Value, seen visits) interface{} {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	seen, ok := seen.enter(v)
+	if !ok {
+		return cycleValue
+	}
+	t := v.Type()
+	m := withMethods(v)
+	switch {
+	case t == timeType:
+		return v.Interface()
+	case m.Type().Implements(objectMarshalerType) || m.Type().Implements(jsonMarshalerType):
+		return m.Interface()
+	case m.Type().Implements(errorType):
+		return p.maskDetected(path, m.Interface().(error).Error())
+	case t == jsonNumberType:
+		if masked := p.maskDetected(path, v.String()); masked != v.String() {
+			return masked
+		}
+		return v.Interface()
+	case m.Type().Implements(textMarshalerType):
+		if text, err := m.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return p.maskDetected(path, string(text))
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return p.maskDetected(path, v.String())
+	case reflect.Struct:
+		fields := cachedStructFields(t)
+		result := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			fv := v.Field(field.index)
+			if field.omitEmpty && fv.IsZero() {
+				continue
+			}
+			if field.mask && p.tagsEnabled {
+				if masked, keep := p.maskFuncFor(field.strategy)(fmt.Sprint(interfaceOf(fv))); keep {
+					result[field.name] = masked
+				}
+				continue
+			}
+			p.plainField(result, path, field.name, fv, seen)
+		}
+		return result
+	case reflect.Map:
+		result := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			p.plainField(result, path, fmt.Sprint(iter.Key().Interface()), iter.Value(), seen)
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		result := make([]interface{}, v.Len())
+		for i := range result {
+			result[i] = p.plainValue(p.childIndex(path, i), v.Index(i), seen)
+		}
+		return result
+	default:
+		return v.Interface()
+	}
+}
+
+// plainField stores the plain value of a field found under the parent path, inside the seen values, into result,
+// masking it when it is sensitive.
+func (p *maskingPolicy) plainField(result map[string]interface{}, parent fieldPath, key string, v reflect.Value, seen visits) {
+	if mask, sensitive := p.match(parent, key); sensitive {
+		if masked, keep := maskValue(mask, interfaceOf(v)); keep {
+			result[key] = masked
+		}
+		return
+	}
+	result[key] = p.plainValue(p.child(parent, key), v, seen)
+}
+
+// withMethods returns a pointer to v when v is addressable, so that the methods with a pointer receiver, such as the
+// MarshalText of big.Int, are found as well.
+func withMethods(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	return v
+}
+
+// hasOwnEncoding reports whether a value defines its own representation, which encodeSpecial writes.
+func hasOwnEncoding(v reflect.Value) bool {
+	if v.Type() == timeType {
+		return true
+	}
+	t := withMethods(v).Type()
+	return t.Implements(objectMarshalerType) || t.Implements(errorType) || t.Implements(textMarshalerType) ||
+		t.Implements(jsonMarshalerType)
+}
+
+// indirect dereferences pointers and interfaces until it reaches a concrete value, or an invalid one for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// interfaceOf returns the dereferenced value held by v, or nil.
+func interfaceOf(v reflect.Value) interface{} {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
@@ -0,0 +1,160 @@
+package zlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+type encoderCard struct {
+	Number string `json:"number" zlog:"mask=last4"`
+	CVC    string `json:"cvc"`
+	Expiry string `json:"-"`
+}
+
+type encoderPayload struct {
+	ID       int           `json:"id"`
+	Owner    string        `zlog:"owner,mask"`
+	Secret   string        `zlog:"omit"`
+	Comment  string        `json:"comment,omitempty"`
+	Card     *encoderCard  `json:"card"`
+	Cards    []encoderCard `json:"cards"`
+	Tags     []string      `json:"tags"`
+	Password string        `json:"password"`
+}
+
+// logWithField logs a single field with the given logger and returns the decoded JSON entry.
+func logWithField(t *testing.T, logger *Logger, key string, value interface{}) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	zl := zerolog.New(&buf)
+	logger.Event(zl.Info()).WithField(key, value).Msg("")
+
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	return entry
+}
+
+// TestStructEncoder verifies that structs are written with their zlog tags and the masking policy applied.
+func TestStructEncoder(t *testing.T) {
+	payload := encoderPayload{
+		ID:       7,
+		Owner:    "John",
+		Secret:   "s3cr3t",
+		Card:     &encoderCard{Number: "4111111111111111", CVC: "123", Expiry: "12/30"},
+		Cards:    []encoderCard{{Number: "5555555555554444", CVC: "456"}},
+		Tags:     []string{"vip"},
+		Password: "P@ssw0rd",
+	}
+
+	t.Run("MaskingEnabled", func(t *testing.T) {
+		logger := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: true}})
+		got := logWithField(t, logger, "payload", payload)["payload"]
+		want := map[string]interface{}{
+			"id":       float64(7),
+			"owner":    redactedValue,
+			"card":     map[string]interface{}{"number": "************1111", "cvc": redactedValue, "Expiry": "12/30"},
+			"cards":    []interface{}{map[string]interface{}{"number": "************4444", "cvc": redactedValue, "Expiry": ""}},
+			"tags":     []interface{}{"vip"},
+			"password": redactedValue,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("MaskingDisabled", func(t *testing.T) {
+		logger := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: false}})
+		got := logWithField(t, logger, "payload", &payload)["payload"].(map[string]interface{})
+		if got["owner"] != "John" || got["password"] != "P@ssw0rd" {
+			t.Errorf("expected unmasked values, got %v", got)
+		}
+		if _, ok := got["Secret"]; ok {
+			t.Errorf("expected omitted field, got %v", got)
+		}
+	})
+
+	t.Run("SensitiveKey", func(t *testing.T) {
+		logger := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: true}})
+		if got := logWithField(t, logger, "password", payload)["password"]; got != redactedValue {
+			t.Errorf("got %v, want %v", got, redactedValue)
+		}
+	})
+}
+
+// TestConvertStructToFieldsKeepsTypes verifies that the conversion keeps the field types and honours omit tags.
+func TestConvertStructToFieldsKeepsTypes(t *testing.T) {
+	logger := New(&Config{Level: "debug"})
+	got := logger.ConvertStructToFields(encoderPayload{ID: 7, Secret: "s3cr3t"})
+	if got["id"] != 7 {
+		t.Errorf("expected int id, got %T %v", got["id"], got["id"])
+	}
+	if _, ok := got["Secret"]; ok {
+		t.Errorf("expected omitted field, got %v", got)
+	}
+}
+
+type encoderNode struct {
+	Label    string         `json:"label"`
+	Next     *encoderNode   `json:"next"`
+	Children []*encoderNode `json:"children"`
+	Links    map[string]interface{}
+}
+
+// TestStructEncoderCycle verifies that a value found inside itself is written once, then replaced by cycleValue.
+func TestStructEncoderCycle(t *testing.T) {
+	node := &encoderNode{Label: "loop", Links: map[string]interface{}{}}
+	node.Next = node
+	node.Children = []*encoderNode{node}
+	node.Links["self"] = node.Links
+	logger := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: true}})
+	want := map[string]interface{}{
+		"label":    "loop",
+		"next":     cycleValue,
+		"children": []interface{}{cycleValue},
+		"Links":    map[string]interface{}{"self": cycleValue},
+	}
+
+	t.Run("Event", func(t *testing.T) {
+		assertJSONEqual(t, logWithField(t, logger, "node", node)["node"], want)
+	})
+	t.Run("ConvertStructToFields", func(t *testing.T) {
+		assertJSONEqual(t, logger.ConvertStructToFields(node), want)
+	})
+	t.Run("Shared", func(t *testing.T) {
+		leaf := &encoderNode{Label: "leaf"}
+		got := logger.ConvertStructToFields(encoderNode{Children: []*encoderNode{leaf, leaf}})
+		if children, _ := got["children"].([]interface{}); len(children) != 2 || children[1] == cycleValue {
+			t.Errorf("expected a shared value written twice, got %v", got)
+		}
+	})
+}
+
+// TestStructEncoderPointerMethods verifies that the marshalers with a pointer receiver are used, as for *big.Int.
+func TestStructEncoderPointerMethods(t *testing.T) {
+	logger := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: true}})
+	payload := struct {
+		Amount  *big.Int
+		Amounts []*big.Int
+		Totals  map[int]*big.Int
+	}{big.NewInt(7), []*big.Int{big.NewInt(8)}, map[int]*big.Int{1: big.NewInt(9)}}
+
+	got := logWithField(t, logger, "payload", payload)["payload"]
+	assertJSONEqual(t, got, map[string]interface{}{"Amount": 7, "Amounts": []interface{}{8}, "Totals": map[string]interface{}{"1": 9}})
+}
+
+// BenchmarkStructEncoder benchmarks writing a tagged struct into an event.
+func BenchmarkStructEncoder(b *testing.B) {
+	logger := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: true}})
+	zl := zerolog.New(&bytes.Buffer{})
+	payload := encoderPayload{ID: 7, Owner: "John", Card: &encoderCard{Number: "4111111111111111"}}
+	for i := 0; i < b.N; i++ {
+		logger.Event(zl.Info()).WithField("payload", payload).Send()
+	}
+}
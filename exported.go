@@ -9,7 +9,8 @@ import (
 // std is the default instance of Logger configured with default settings.
 var std = newStandardLogger()
 
-// NewLogger initializes the global logger instance with the provided configuration.
+// NewLogger replaces the package-level logger with a Logger created with the provided configuration. The zerolog
+// globals are left untouched; use Logger.SetAsGlobal to apply the Logger to them as well.
 func NewLogger(config *Config) {
 	std = newLogger(config)
 }
@@ -31,30 +32,30 @@ func AddCallerSkip(ctx context.Context, skip int) context.Context {
 
 // Debug logs a message at the debug level and returns an Event object to further customize the log entry.
 func Debug() *Event {
-	return &Event{std.Logger.Debug()}
+	return std.Event(std.Logger.Debug())
 }
 
 // Info creates a new event at the info log level.
 func Info() *Event {
-	return &Event{std.Logger.Info()}
+	return std.Event(std.Logger.Info())
 }
 
 // Warn creates a log event at the warn level using the standard logger.
 func Warn() *Event {
-	return &Event{std.Logger.Warn()}
+	return std.Event(std.Logger.Warn())
 }
 
 // Error returns a new Event with the logging level set to 'error'.
 func Error() *Event {
-	return &Event{std.Logger.Error()}
+	return std.Event(std.Logger.Error())
 }
 
 // Fatal creates an Event with fatal log level
 func Fatal() *Event {
-	return &Event{std.Logger.Fatal()}
+	return std.Event(std.Logger.Fatal())
 }
 
 // Panic creates a new logging event at the panic level using the standard logger and returns an Event pointer.
 func Panic() *Event {
-	return &Event{std.Logger.Panic()}
+	return std.Event(std.Logger.Panic())
 }
@@ -7,7 +7,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 
-	"gitlab-dev.tripperpix.com/vb-mobile-backend/channel-api/batch/batch-card-apply-task/pkg/zlogs"
+	"github.com/Jdemon/zlogs"
 )
 
 var data = map[string]any{
@@ -0,0 +1,283 @@
+package zlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Format selects how the entries are written to the outputs.
+type Format string
+
+// FormatJSON writes one JSON object per entry.
+// FormatConsole writes human-friendly lines for local development, colored when the output is a terminal.
+// FormatLogfmt writes key=value pairs, the nested fields flattened into dotted keys.
+const (
+	FormatJSON    Format = "json"
+	FormatConsole Format = "console"
+	FormatLogfmt  Format = "logfmt"
+)
+
+// consoleTimeFormat is the layout of the timestamps written by the console format.
+const consoleTimeFormat = "15:04:05"
+
+// consoleColumns are the keys written as aligned columns between the severity and the message.
+var consoleColumns = []string{appNameKey, traceIDKey}
+
+// ANSI color codes of the console format.
+const (
+	colorRed     = 31
+	colorGreen   = 32
+	colorYellow  = 33
+	colorBlue    = 34
+	colorCyan    = 36
+	colorGray    = 90
+	colorBoldRed = 91
+)
+
+// levelColors colors the severity of the console format.
+var levelColors = map[zerolog.Level]int{
+	zerolog.TraceLevel: colorGray,
+	zerolog.DebugLevel: colorBlue,
+	zerolog.InfoLevel:  colorGreen,
+	zerolog.WarnLevel:  colorYellow,
+	zerolog.ErrorLevel: colorRed,
+	zerolog.FatalLevel: colorBoldRed,
+	zerolog.PanicLevel: colorBoldRed,
+}
+
+// formatWriter wraps the writer of an output with the configured format. An empty format selects the console format
+// when the output is a terminal, and JSON otherwise.
+func formatWriter(format Format, w io.Writer, names FieldNames) io.Writer {
+	switch format {
+	case FormatConsole:
+		return newConsoleWriter(w, names)
+	case FormatLogfmt:
+		return newLogfmtWriter(w)
+	case "":
+		if isTerminal(w) {
+			return newConsoleWriter(w, names)
+		}
+	}
+	return w
+}
+
+// isTerminal reports whether the writer is a terminal.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// consoleWriter is an io.Writer turning the JSON lines of the entries into human-friendly lines: timestamp,
+// severity, aligned appName and trace_id columns, message, then the other fields, the nested ones pretty-printed.
+type consoleWriter struct {
+	out          io.Writer
+	color        bool
+	timestampKey string
+	levelKey     string
+	messageKey   string
+
+	mu     sync.Mutex
+	widths map[string]int
+}
+
+// newConsoleWriter returns a consoleWriter writing to out the entries with the given field names, colored when out
+// is a terminal and NO_COLOR is not set.
+func newConsoleWriter(out io.Writer, names FieldNames) *consoleWriter {
+	return &consoleWriter{
+		out:          out,
+		color:        isTerminal(out) && os.Getenv("NO_COLOR") == "",
+		timestampKey: names.Timestamp,
+		levelKey:     names.Level,
+		messageKey:   names.Message,
+		widths:       make(map[string]int),
+	}
+}
+
+// Write formats every line of p and writes them to the underlying writer.
+func (w *consoleWriter) Write(p []byte) (int, error) {
+	formatted := mapLines(p, w.formatLine)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.out.Write(formatted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// jsonField is a top-level field of an entry, in the order of the JSON line.
+type jsonField struct {
+	key string
+	raw json.RawMessage
+}
+
+// formatLine formats a JSON line, or returns it untouched when it is not a JSON object.
+func (w *consoleWriter) formatLine(line []byte) []byte {
+	fields, err := decodeFields(line)
+	if err != nil {
+		return line
+	}
+	values := make(map[string]string, len(fields))
+	var rest []jsonField
+	for _, field := range fields {
+		switch field.key {
+		case w.timestampKey, w.levelKey, w.messageKey, appNameKey, traceIDKey:
+			values[field.key] = jsonText(field.raw)
+		default:
+			rest = append(rest, field)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(w.colorize(consoleTime(values[w.timestampKey]), colorGray))
+	buf.WriteByte(' ')
+	buf.WriteString(w.formatLevel(values[w.levelKey]))
+	for _, column := range consoleColumns {
+		if value, ok := w.column(column, values); ok {
+			buf.WriteString(" [")
+			buf.WriteString(w.colorize(value, colorCyan))
+			buf.WriteByte(']')
+		}
+	}
+	if message := values[w.messageKey]; message != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(message)
+	}
+
+	var nested []jsonField
+	for _, field := range rest {
+		if field.raw[0] == '{' || field.raw[0] == '[' {
+			nested = append(nested, field)
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(w.colorize(field.key+"=", colorCyan))
+		buf.WriteString(consoleScalar(field.raw))
+	}
+	for _, field := range nested {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, field.raw, "    ", "  "); err != nil {
+			indented.Write(field.raw)
+		}
+		buf.WriteString("\n    ")
+		buf.WriteString(w.colorize(field.key+"=", colorCyan))
+		buf.Write(indented.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// column returns the value of a column padded to the widest value seen so far. A column is written only once a
+// value has been seen for it, so that the messages stay aligned.
+func (w *consoleWriter) column(key string, values map[string]string) (string, bool) {
+	value := values[key]
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(value) > w.widths[key] {
+		w.widths[key] = len(value)
+	}
+	if w.widths[key] == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%-*s", w.widths[key], value), true
+}
+
+// formatLevel returns the short, colored name of a severity.
+func (w *consoleWriter) formatLevel(value string) string {
+	level, err := zerolog.ParseLevel(value)
+	if err != nil || value == "" {
+		return fmt.Sprintf("%-3s", strings.ToUpper(value))
+	}
+	label, ok := zerolog.FormattedLevels[level]
+	if !ok {
+		label = strings.ToUpper(value)
+	}
+	return w.colorize(label, levelColors[level])
+}
+
+// colorize wraps the text with an ANSI color when colors are enabled.
+func (w *consoleWriter) colorize(text string, color int) string {
+	if !w.color || color == 0 {
+		return text
+	}
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", color, text)
+}
+
+// mapLines applies fn to every non-blank line of p, keeping the line breaks.
+func mapLines(p []byte, fn func(line []byte) []byte) []byte {
+	var buf bytes.Buffer
+	for len(p) > 0 {
+		line, rest, found := bytes.Cut(p, []byte{'\n'})
+		if len(bytes.TrimSpace(line)) > 0 {
+			buf.Write(fn(line))
+		}
+		if found {
+			buf.WriteByte('\n')
+		}
+		p = rest
+	}
+	return buf.Bytes()
+}
+
+// decodeFields decodes the top-level fields of a JSON object, keeping their order.
+func decodeFields(line []byte) ([]jsonField, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return nil, errors.New("zlogs: not a JSON object")
+	}
+	var fields []jsonField
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		fields = append(fields, jsonField{key: key, raw: raw})
+	}
+	return fields, nil
+}
+
+// jsonText returns a JSON value as plain text, unquoting the strings.
+func jsonText(raw json.RawMessage) string {
+	var s string
+	if raw[0] != '"' || json.Unmarshal(raw, &s) != nil {
+		return string(raw)
+	}
+	return s
+}
+
+// consoleScalar returns a scalar JSON value as text, quoting the strings only when they are empty or contain spaces.
+func consoleScalar(raw json.RawMessage) string {
+	var s string
+	if raw[0] != '"' || json.Unmarshal(raw, &s) != nil {
+		return string(raw)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return string(raw)
+	}
+	return s
+}
+
+// consoleTime formats a timestamp of the entry for the console, or returns it untouched when it cannot be parsed.
+func consoleTime(value string) string {
+	t, err := time.Parse(zerolog.TimeFieldFormat, value)
+	if err != nil {
+		return value
+	}
+	return t.Local().Format(consoleTimeFormat)
+}
@@ -0,0 +1,73 @@
+package zlogs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestConsoleFormat verifies the layout of the console format: severity, columns, message, fields and nested fields.
+func TestConsoleFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{
+		AppName: "orders",
+		Level:   "debug",
+		Format:  FormatConsole,
+		Masking: MaskingConfig{Enabled: true},
+		Outputs: []OutputConfig{{Writer: &buf}},
+	})
+	logger.Event(logger.Info()).
+		WithField("user", "john doe").
+		WithField("data", map[string]interface{}{"password": "P@ss"}).
+		Msg("order created")
+
+	got := buf.String()
+	for _, want := range []string{" INF [orders] order created", `user="john doe"`, "\n    data={\n", `"password": "***"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("expected no colors outside a terminal, got %q", got)
+	}
+}
+
+// TestConsoleColumns verifies that the columns are padded to the widest value seen.
+func TestConsoleColumns(t *testing.T) {
+	var buf bytes.Buffer
+	w := newConsoleWriter(&buf, defaultFieldNames)
+	_, _ = w.Write([]byte(`{"severity":"info","trace_id":"abcdef","message":"first"}` + "\n"))
+	_, _ = w.Write([]byte(`{"severity":"warn","trace_id":"abc","message":"second"}` + "\n"))
+	_, _ = w.Write([]byte(`{"severity":"error","message":"third"}` + "\n"))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{" INF [abcdef] first", " WRN [abc   ] second", " ERR [      ] third"}
+	for i, line := range lines {
+		if line != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, line, want[i])
+		}
+	}
+}
+
+// TestConsoleNotJSON verifies that the lines which are not JSON objects are written untouched.
+func TestConsoleNotJSON(t *testing.T) {
+	var buf bytes.Buffer
+	_, _ = newConsoleWriter(&buf, defaultFieldNames).Write([]byte("plain text\n"))
+	if got := buf.String(); got != "plain text\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// TestFormatWriter verifies that JSON is kept unless the console format is selected or the output is a terminal.
+func TestFormatWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if w := formatWriter("", &buf, defaultFieldNames); w != &buf {
+		t.Errorf("expected JSON for a buffer, got %T", w)
+	}
+	if w := formatWriter(FormatJSON, &buf, defaultFieldNames); w != &buf {
+		t.Errorf("expected JSON, got %T", w)
+	}
+	if _, ok := formatWriter(FormatConsole, &buf, defaultFieldNames).(*consoleWriter); !ok {
+		t.Error("expected a consoleWriter")
+	}
+}
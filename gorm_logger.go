@@ -2,7 +2,6 @@ package zlogs
 
 import (
 	"context"
-	"os"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -13,17 +12,16 @@ type GORMLogger struct {
 	*zerolog.Logger
 }
 
+// NewGORMLogger creates a GORM logger without caller from the config, writing to its own outputs. Use
+// NewGORMLoggerFrom to share the outputs of an existing Logger instead.
 func NewGORMLogger(config *Config) *GORMLogger {
-	loggerGORM := zerolog.New(os.Stdout).Hook(&InitHook{
-		AppName:       config.AppName,
-		DisableCaller: true,
-	}).With().Timestamp().Logger()
+	return NewGORMLoggerFrom(New(config))
+}
 
-	if lvl, err := zerolog.ParseLevel(config.Level); err != nil {
-		loggerGORM.Level(zerolog.DebugLevel)
-	} else {
-		loggerGORM.Level(lvl)
-	}
+// NewGORMLoggerFrom creates a GORM logger writing to the outputs of l, without caller. It follows the level and the
+// masking of l, including the changes made by SetLevel and Reload.
+func NewGORMLoggerFrom(l *Logger) *GORMLogger {
+	loggerGORM := newZerologLogger(&l.config, l.output.writer, true, l.levels)
 	return &GORMLogger{
 		&loggerGORM,
 	}
@@ -0,0 +1,52 @@
+package zlogs
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGORMLogger verifies that the GORM logger built from a Logger writes to the output of its Logger and follows its level and masking.
+func TestGORMLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{AppName: "orders", Level: "info", Masking: MaskingConfig{Enabled: true},
+		Outputs: []OutputConfig{{Writer: &buf}}})
+	gormLogger := NewGORMLoggerFrom(logger)
+	ctx := WithFields(context.Background(), map[string]interface{}{"password": "P@ss"})
+	query := func() (string, int64) { return "SELECT 1", 1 }
+
+	gormLogger.Info(ctx, "connected to %s", "db")
+	gormLogger.Trace(ctx, time.Now(), query, nil)
+	if err := logger.SetLevel("trace"); err != nil {
+		t.Fatal(err)
+	}
+	gormLogger.Trace(ctx, time.Now(), query, nil)
+
+	if strings.Contains(buf.String(), "P@ss") {
+		t.Errorf("expected the context fields masked, got %s", buf.String())
+	}
+	entries := decodeEntries(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected the info entry and the trace entry after SetLevel, got %d entries", len(entries))
+	}
+	if entries[0]["message"] != "connected to db" || entries[0][appNameKey] != "orders" ||
+		entries[0][fileKey] != nil || entries[0][funcKey] != nil {
+		t.Errorf("unexpected info entry %v", entries[0])
+	}
+	if entries[1]["sql"] != "SELECT 1" || entries[1]["rows"] != float64(1) {
+		t.Errorf("unexpected trace entry %v", entries[1])
+	}
+}
+
+// TestNewGORMLogger verifies that the GORM logger built from a config writes to the outputs of the config.
+func TestNewGORMLogger(t *testing.T) {
+	var buf bytes.Buffer
+	NewGORMLogger(&Config{Level: "info", CallerEnable: true, Outputs: []OutputConfig{{Writer: &buf}}}).
+		Warn(context.Background(), "slow query")
+	entries := decodeEntries(t, &buf)
+	if len(entries) != 1 || entries[0]["message"] != "slow query" || entries[0][fileKey] != nil {
+		t.Errorf("unexpected entries %v", entries)
+	}
+}
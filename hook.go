@@ -1,6 +1,7 @@
 package zlogs
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 	"strings"
@@ -11,9 +12,9 @@ import (
 // contextKey is a custom type used for defining unique keys for context values in Go applications.
 type contextKey int
 
-// TraceID is used as a context key for tracing the execution path of a request.
-// CorrelationID is used as a context key for grouping and correlating related log entries.
-// RequestID is used as a context key for uniquely identifying an individual request.
+// TraceID is used as a context key for tracing the execution path of a request, set by WithTraceID.
+// CorrelationID is used as a context key for grouping and correlating related log entries, set by WithCorrelationID.
+// RequestID is used as a context key for uniquely identifying an individual request, set by WithRequestID.
 // CallerSkip is used as a context key for controlling the skip level in caller information retrieval.
 const (
 	TraceID contextKey = iota
@@ -22,16 +23,74 @@ const (
 	CallerSkip
 )
 
+// Keys of the fields added by InitHook.
+const (
+	traceIDKey       = "trace_id"
+	spanIDKey        = "span_id"
+	requestIDKey     = "request_id"
+	correlationIDKey = "correlation_id"
+	fileKey          = "file"
+	funcKey          = "func"
+)
+
+// ContextExtractor returns a field to add to the entries logged with a context, e.g. a tenant ID stored by a web
+// framework, and whether the context carries it.
+type ContextExtractor func(ctx context.Context) (key string, value any, ok bool)
+
+// DefaultContextExtractors adds the IDs set by WithTraceID, WithRequestID and WithCorrelationID, and the span
+// context of SpanContextFromContext. It is used when Config.ContextExtractors is nil; append to it to keep them
+// along with custom extractors.
+var DefaultContextExtractors = defaultContextExtractors()
+
+// defaultContextExtractors returns the DefaultContextExtractors. The trace ID of a span context takes precedence over
+// the raw value under the TraceID key.
+func defaultContextExtractors() []ContextExtractor {
+	spanContext := SpanContextExtractors(SpanContextFromContext)
+	return []ContextExtractor{
+		traceIDOrValue(spanContext[0]),
+		spanContext[1],
+		spanContext[2],
+		ContextValue(requestIDKey, RequestID),
+		ContextValue(correlationIDKey, CorrelationID),
+	}
+}
+
+// ContextValue returns a ContextExtractor adding the value stored in a context under ctxKey as the field key, when
+// it is neither nil nor an empty string.
+func ContextValue(key string, ctxKey any) ContextExtractor {
+	return func(ctx context.Context) (string, any, bool) {
+		value := ctx.Value(ctxKey)
+		return key, value, value != nil && value != ""
+	}
+}
+
+// defaultCallerSkip is the number of stack frames between InitHook.Run and the code that sent the event.
+const defaultCallerSkip = 5
+
 // InitHook is a type used to initialize log entries with application-specific data and optionally include caller information.
 type InitHook struct {
 	AppName       string
 	DisableCaller bool
+	// Extractors add fields from the context of the entries, DefaultContextExtractors when nil.
+	Extractors []ContextExtractor
+	// levels checks the level again, as zerolog.DisableSampling skips the levelGate that filters the entries, and
+	// holds the policy masking the fields of the context, which are written unmasked when it is nil.
+	levels *levelGate
 }
 
-// Run sets entry data and caller information into the zerolog event. It retrieves context values and updates the event accordingly.
-func (h *InitHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
-	var callerSkip = 5
-	if !h.DisableCaller {
+// Run sets entry data and caller information into the zerolog event. It adds the fields of the extractors and the
+// ones set by WithFields from the context of the event, both masked with the policy, unless Event.send has already
+// added them. It discards the entries below the level of the Logger.
+func (h *InitHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	var state *loggerState
+	if h.levels != nil {
+		if state = h.levels.state.Load(); level < h.levels.effectiveLevel(state) {
+			e.Discard()
+			return
+		}
+	}
+	var callerSkip = defaultCallerSkip
+	if !h.DisableCaller && e.GetCtx().Value(noCallerKey) == nil {
 		if skip, ok := e.GetCtx().Value(CallerSkip).(int); ok {
 			callerSkip = skip
 		}
@@ -39,12 +98,37 @@ func (h *InitHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
 	}
 
 	setEntryData(e, appNameKey, h.AppName)
-	if e.GetCtx() == nil {
+	if e.GetCtx() == nil || e.GetCtx().Value(contextAddedKey) != nil {
 		return
 	}
-	setEntryData(e, "trace_id", e.GetCtx().Value(TraceID))
-	setEntryData(e, "request_id", e.GetCtx().Value(RequestID))
-	setEntryData(e, "correlation_id", e.GetCtx().Value(CorrelationID))
+	var policy *maskingPolicy
+	if state != nil {
+		policy = state.policy
+	}
+	addContext(e, e.GetCtx(), h.Extractors, policy)
+}
+
+// addContext adds the fields of the extractors, DefaultContextExtractors when nil, and the ones set by WithFields
+// from the context to the event, both masked with the policy.
+func addContext(e *zerolog.Event, ctx context.Context, extractors []ContextExtractor, policy *maskingPolicy) {
+	if extractors == nil {
+		extractors = DefaultContextExtractors
+	}
+	for _, extract := range extractors {
+		if key, value, ok := extract(ctx); ok {
+			addMaskedField(e, key, value, policy)
+		}
+	}
+	addContextFields(e, ctx, policy)
+}
+
+// timestampHook adds the time of the entry under its field name, so that the name is specific to the Logger instead
+// of the zerolog.TimestampFieldName global.
+type timestampHook string
+
+// Run adds the current time to the zerolog event.
+func (h timestampHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	e.Time(string(h), zerolog.TimestampFunc())
 }
 
 // setEntryData adds a key-value pair to the given zerolog event if the value is neither nil nor an empty string.
@@ -57,8 +141,8 @@ func setEntryData(e *zerolog.Event, key string, value interface{}) {
 // caller enriches the provided zerolog.Event by adding file and function name information based on the stack skip level.
 func caller(event *zerolog.Event, skip int) *zerolog.Event {
 	file, fnc := fileInfo(skip)
-	event.Str("file", file)
-	event.Str("func", fnc)
+	event.Str(fileKey, file)
+	event.Str(funcKey, fnc)
 	return event
 }
 
@@ -70,3 +70,73 @@ func TestInitHook_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestInitHook_Extractors(t *testing.T) {
+	type ginKey string
+	tenant := func(ctx context.Context) (string, any, bool) {
+		value, ok := ctx.Value(ginKey("tenant")).(string)
+		return "tenant_id", value, ok
+	}
+	ctx := context.WithValue(createTestContext(), ginKey("tenant"), "acme")
+	ctx = context.WithValue(ctx, ginKey("user"), "u-1")
+
+	testCases := []struct {
+		name       string
+		extractors []ContextExtractor
+		contains   []string
+		excludes   []string
+	}{
+		{
+			name:     "should use the default extractors when nil",
+			contains: []string{`"trace_id":"12345"`, `"request_id":"req-67890"`, `"correlation_id":"corr-abcde"`},
+			excludes: []string{`"tenant_id"`},
+		},
+		{
+			name:       "should replace the default extractors",
+			extractors: []ContextExtractor{tenant, ContextValue("user_id", ginKey("user")), ContextValue("missing", ginKey("none"))},
+			contains:   []string{`"tenant_id":"acme"`, `"user_id":"u-1"`},
+			excludes:   []string{`"trace_id"`, `"missing"`},
+		},
+		{
+			name:       "should extend the default extractors",
+			extractors: append(append([]ContextExtractor{}, DefaultContextExtractors...), tenant),
+			contains:   []string{`"trace_id":"12345"`, `"tenant_id":"acme"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&Config{ContextExtractors: tc.extractors, Outputs: []OutputConfig{{Writer: &buf}}})
+			logger.Info().Ctx(ctx).Msg("test log")
+
+			for _, field := range tc.contains {
+				assert.Contains(t, buf.String(), field)
+			}
+			for _, field := range tc.excludes {
+				assert.NotContains(t, buf.String(), field)
+			}
+		})
+	}
+}
+
+// TestInitHook_ExtractorsMasked verifies that the fields of the extractors are masked like the other context fields.
+func TestInitHook_ExtractorsMasked(t *testing.T) {
+	type ctxKey string
+	ctx := context.WithValue(context.Background(), ctxKey("token"), "s3cr3t")
+	ctx = context.WithValue(ctx, ctxKey("user"), "john@doe.com")
+	var buf bytes.Buffer
+	logger := New(&Config{
+		Masking: MaskingConfig{Enabled: true, SensitiveFields: []string{"api_token"}, Detectors: []string{DetectorEmail}},
+		ContextExtractors: []ContextExtractor{
+			ContextValue("api_token", ctxKey("token")),
+			ContextValue("user", ctxKey("user")),
+		},
+		Outputs: []OutputConfig{{Writer: &buf}},
+	})
+	logger.Info().Ctx(ctx).Msg("test log")
+
+	assert.Contains(t, buf.String(), `"api_token":"***"`)
+	assert.NotContains(t, buf.String(), "s3cr3t")
+	assert.NotContains(t, buf.String(), "john@doe.com")
+}
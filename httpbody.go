@@ -0,0 +1,164 @@
+package zlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultMaxBodySize is the number of bytes captured from a body when HTTPConfig.MaxBodySize is zero.
+const defaultMaxBodySize = 16 << 10
+
+// Keys of the bodies in the access log.
+const (
+	requestBodyKey  = "request_body"
+	responseBodyKey = "response_body"
+	truncatedSuffix = "_truncated"
+	sizeSuffix      = "_size"
+)
+
+// bodyCapture is the beginning of a body captured for the access log.
+type bodyCapture struct {
+	data      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// write captures p up to the limit, recording whether the body is truncated.
+func (c *bodyCapture) write(p []byte) {
+	if room := c.limit - c.data.Len(); len(p) > room {
+		p = p[:room]
+		c.truncated = true
+	}
+	c.data.Write(p)
+}
+
+// maxBodySize returns the number of bytes captured from a body.
+func (c HTTPConfig) maxBodySize() int {
+	if c.MaxBodySize <= 0 {
+		return defaultMaxBodySize
+	}
+	return c.MaxBodySize
+}
+
+// captureRequestBody captures the beginning of the body of a request and restores it for the handlers, which read
+// the captured bytes followed by the rest of the body. It returns nil for a request without a body.
+func captureRequestBody(r *http.Request, limit int) *bodyCapture {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	capture := &bodyCapture{limit: limit}
+	data, _ := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+	capture.write(data)
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), r.Body), Closer: r.Body}
+	return capture
+}
+
+// readCloser is a body reading from Reader and closed by Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// withBody adds a captured body to the event under key, masked with the policy of the Logger: the fields of a JSON
+// or form body, or the text of another textual body, masked like a message. Binary and encoded bodies are left out,
+// as are the truncated JSON and form bodies, whose last value may be cut before the masking rules can match it; only
+// their size is added, when it is not negative.
+func (e *Event) withBody(key string, header http.Header, capture *bodyCapture, size int64) *Event {
+	if e.Event == nil || capture == nil || capture.data.Len() == 0 || header.Get("Content-Encoding") != "" {
+		return e
+	}
+	body := capture.data.Bytes()
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !isTextMediaType(mediaType) {
+		return e
+	}
+
+	isForm := mediaType == "application/x-www-form-urlencoded"
+	if capture.truncated {
+		e.Event = e.Bool(key+truncatedSuffix, true)
+		if isForm || isJSONMediaType(mediaType) {
+			if size >= 0 {
+				e.Event = e.Int64(key+sizeSuffix, size)
+			}
+			return e
+		}
+	}
+
+	l := e.getLogger()
+	var value interface{}
+	switch {
+	case isForm:
+		value = l.maskFields(nil, formFields(body))
+	case isJSONMediaType(mediaType):
+		value = l.maskJSONBody(body)
+	default:
+		value = l.maskMessage(string(body))
+	}
+	e.Event = e.Interface(key, value)
+	return e
+}
+
+// maskJSONBody masks the fields of a JSON body, or masks it like a message when it is not valid JSON.
+func (l *Logger) maskJSONBody(body []byte) interface{} {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil || dec.More() {
+		return l.maskMessage(string(body))
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return l.maskFields(nil, v)
+	case []interface{}:
+		return l.maskArrayFields(nil, v)
+	case string:
+		return l.maskMessage(v)
+	}
+	return value
+}
+
+// formFields returns the fields of a URL-encoded form, with a list for the repeated ones. The pairs that cannot be
+// parsed are left out, as they could not be masked by their key.
+func formFields(body []byte) map[string]interface{} {
+	values, _ := url.ParseQuery(string(body))
+	fields := make(map[string]interface{}, len(values))
+	for key, list := range values {
+		if len(list) == 1 {
+			fields[key] = list[0]
+			continue
+		}
+		items := make([]interface{}, len(list))
+		for i, item := range list {
+			items[i] = item
+		}
+		fields[key] = items
+	}
+	return fields
+}
+
+// isTextMediaType reports whether a body of the media type is text that can be logged.
+func isTextMediaType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"), isJSONMediaType(mediaType), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "application/xml", "application/javascript", "application/graphql":
+		return true
+	}
+	return false
+}
+
+// isJSONMediaType reports whether the media type is JSON, such as application/json or application/problem+json.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
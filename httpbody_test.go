@@ -0,0 +1,133 @@
+package zlogs
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestHTTPMiddlewareBodies verifies that the captured bodies are masked and restored for the handler.
+func TestHTTPMiddlewareBodies(t *testing.T) {
+	cases := []struct {
+		name         string
+		contentType  string
+		body         string
+		wantRequest  interface{}
+		wantResponse interface{}
+	}{
+		{"JSON", "application/json", `{"user":"jo","password":"P@ss","amount":12.50}`,
+			map[string]interface{}{"user": "jo", "password": redactedValue, "amount": 12.5},
+			map[string]interface{}{"user": "jo", "password": redactedValue, "amount": 12.5}},
+		{"JSONArray", "application/problem+json", `[{"password":"P@ss"}]`,
+			[]interface{}{map[string]interface{}{"password": redactedValue}},
+			[]interface{}{map[string]interface{}{"password": redactedValue}}},
+		{"Form", "application/x-www-form-urlencoded", "user=jo&password=P%40ss&tag=a&tag=b",
+			map[string]interface{}{"user": "jo", "password": redactedValue, "tag": []interface{}{"a", "b"}},
+			map[string]interface{}{"user": "jo", "password": redactedValue, "tag": []interface{}{"a", "b"}}},
+		{"InvalidForm", "application/x-www-form-urlencoded", "password=P@ss&x=%zz&user=jo",
+			map[string]interface{}{"user": "jo", "password": redactedValue},
+			map[string]interface{}{"user": "jo", "password": redactedValue}},
+		{"Text", "text/plain; charset=utf-8", "login password=P@ss",
+			"login password=***", "login password=***"},
+		{"InvalidJSON", "application/json", `{"password":"P@ss"`,
+			`{"password":"***"`, `{"password":"***"`},
+		{"Binary", "application/octet-stream", "\x00\x01password", nil, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", tc.contentType)
+			config := HTTPConfig{OmitHeaders: true, LogRequestBody: true, LogResponseBody: true}
+			serveMiddleware(t, &buf, config, func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != tc.body {
+					t.Errorf("expected the body restored, got %q", body)
+				}
+				w.Header().Set("Content-Type", tc.contentType)
+				_, _ = w.Write(body)
+			}, r)
+
+			if strings.Contains(buf.String(), "P@ss") {
+				t.Errorf("expected the bodies masked, got %s", buf.String())
+			}
+			access := decodeEntries(t, &buf)[0]
+			assertJSONEqual(t, access[requestBodyKey], tc.wantRequest)
+			assertJSONEqual(t, access[responseBodyKey], tc.wantResponse)
+		})
+	}
+}
+
+// TestHTTPMiddlewareBodyLimit verifies the truncation of the bodies longer than the limit, cut in the middle of a
+// sensitive value.
+func TestHTTPMiddlewareBodyLimit(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		want        interface{}
+	}{
+		{"JSON", "application/json", `{"user":"bob","password":"hunter2-very-long-secret"}`, nil},
+		{"Form", "application/x-www-form-urlencoded", "user=bob&password=hunter2-very-long-secret", nil},
+		{"Text", "text/plain", "login password=hunter2 " + strings.Repeat("x", 64), "login password=*** xxxxxxxxx"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", tc.contentType)
+			config := HTTPConfig{OmitHeaders: true, LogRequestBody: true, LogResponseBody: true, MaxBodySize: 32}
+			serveMiddleware(t, &buf, config, func(w http.ResponseWriter, r *http.Request) {
+				got, _ := io.ReadAll(r.Body)
+				if string(got) != tc.body {
+					t.Errorf("expected the whole body restored, got %q", got)
+				}
+				w.Header().Set("Content-Type", tc.contentType)
+				_, _ = w.Write(got[:20])
+				_, _ = w.Write(got[20:])
+			}, r)
+
+			if strings.Contains(buf.String(), "hunter") {
+				t.Errorf("expected the cut value masked, got %s", buf.String())
+			}
+			var wantSize interface{}
+			if tc.want == nil {
+				wantSize = float64(len(tc.body))
+			}
+			access := decodeEntries(t, &buf)[0]
+			for _, key := range []string{requestBodyKey, responseBodyKey} {
+				if access[key] != tc.want || access[key+truncatedSuffix] != true || access[key+sizeSuffix] != wantSize {
+					t.Errorf("unexpected %s in %v", key, access)
+				}
+			}
+		})
+	}
+}
+
+// TestHTTPMiddlewareBodyOptIn verifies that the bodies are only captured when asked, and never when encoded.
+func TestHTTPMiddlewareBodyOptIn(t *testing.T) {
+	var buf bytes.Buffer
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`))
+	serveMiddleware(t, &buf, HTTPConfig{LogResponseBody: true}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write([]byte(`{"a":1}`))
+	}, r)
+	access := decodeEntries(t, &buf)[0]
+	if _, ok := access[requestBodyKey]; ok {
+		t.Errorf("expected no request body, got %v", access)
+	}
+	if _, ok := access[responseBodyKey]; ok {
+		t.Errorf("expected no encoded response body, got %v", access)
+	}
+}
+
+// assertJSONEqual compares a decoded JSON value to the expected one through their JSON encoding.
+func assertJSONEqual(t *testing.T, got, want interface{}) {
+	t.Helper()
+	if g, w := string(marshalJSON(got)), string(marshalJSON(want)); g != w {
+		t.Errorf("got %s, want %s", g, w)
+	}
+}
@@ -0,0 +1,274 @@
+package zlogs
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// packagePath is the import path of this package, whose frames are skipped when looking for the caller package.
+var packagePath = reflect.TypeOf(Logger{}).PkgPath()
+
+// levelOverrides holds the levels overriding the level of the loggers, per AppName and per package. It is never
+// modified once published; the setters publish a modified copy.
+type levelOverrides struct {
+	apps     map[string]zerolog.Level
+	packages map[string]zerolog.Level
+}
+
+var (
+	// overrides are the current level overrides, nil when there are none.
+	overrides atomic.Pointer[levelOverrides]
+	// overridesMu serializes the updates of the overrides.
+	overridesMu sync.Mutex
+	// callerPackage returns the import path of the package that logs the current entry.
+	callerPackage = findCallerPackage
+)
+
+// SetAppLevel overrides the level of every Logger of the application named appName. An empty level removes the
+// override.
+func SetAppLevel(appName, level string) error {
+	return setOverride(appName, level, func(o *levelOverrides) map[string]zerolog.Level { return o.apps })
+}
+
+// SetPackageLevel overrides the level of the entries logged from the package with the given import path, or from
+// its sub-packages, whichever Logger they use. An empty level removes the override.
+func SetPackageLevel(pkg, level string) error {
+	return setOverride(pkg, level, func(o *levelOverrides) map[string]zerolog.Level { return o.packages })
+}
+
+// AppLevels returns the level overrides per AppName.
+func AppLevels() map[string]string {
+	o := overrides.Load()
+	if o == nil {
+		return map[string]string{}
+	}
+	return levelNames(o.apps)
+}
+
+// PackageLevels returns the level overrides per package.
+func PackageLevels() map[string]string {
+	o := overrides.Load()
+	if o == nil {
+		return map[string]string{}
+	}
+	return levelNames(o.packages)
+}
+
+// setOverride publishes a copy of the overrides where the override of key in the map selected by target is set,
+// or removed when level is empty.
+func setOverride(key, level string, target func(o *levelOverrides) map[string]zerolog.Level) error {
+	var parsed zerolog.Level
+	if level != "" {
+		var err error
+		if parsed, err = parseLevelStrict(level); err != nil {
+			return err
+		}
+	}
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	next := &levelOverrides{apps: map[string]zerolog.Level{}, packages: map[string]zerolog.Level{}}
+	if current := overrides.Load(); current != nil {
+		for app, l := range current.apps {
+			next.apps[app] = l
+		}
+		for pkg, l := range current.packages {
+			next.packages[pkg] = l
+		}
+	}
+	if level == "" {
+		delete(target(next), key)
+	} else {
+		target(next)[key] = parsed
+	}
+	if len(next.apps) == 0 && len(next.packages) == 0 {
+		next = nil
+	}
+	overrides.Store(next)
+	return nil
+}
+
+// levelGate decides whether the entries of a Logger are written, from its level and the overrides. It is the
+// zerolog.Sampler of the Logger, so that the level can change while the Logger is in use. As zerolog.DisableSampling
+// turns the samplers off, InitHook checks the level as well and discards the entries the gate would have dropped;
+// only their fields have been built by then.
+type levelGate struct {
+	appName string
+	state   atomic.Pointer[loggerState]
+}
+
+// loggerState is the part of a Logger that SetLevel and Reload change while it is in use. It is never modified once
+// published, so that an entry sees the level, the sampling and the masking policies of a single Reload.
+type loggerState struct {
+	level    zerolog.Level
+	sampling *sampler
+	// policy masks the events, and outputPolicy the lines written when masking happens at the output.
+	policy       *maskingPolicy
+	outputPolicy *maskingPolicy
+}
+
+// newLoggerState returns the state of a Logger with the given level, sampling and masking.
+func newLoggerState(level zerolog.Level, sampling *sampler, masking MaskingConfig) *loggerState {
+	state := &loggerState{level: level, sampling: sampling, policy: newEventPolicy(masking)}
+	if masking.AtOutput {
+		state.outputPolicy = newMaskingPolicy(masking)
+	}
+	return state
+}
+
+// newLevelGate returns the levelGate of the config. An invalid sampling level samples the info level and below.
+func newLevelGate(config *Config) *levelGate {
+	gate := &levelGate{appName: config.AppName}
+	sampling := config.Sampling
+	if _, err := zerolog.ParseLevel(sampling.Level); err != nil {
+		sampling.Level = ""
+	}
+	s, _ := newSampler(sampling)
+	gate.state.Store(newLoggerState(parseLevel(config.Level), s, config.Masking))
+	return gate
+}
+
+// Sample reports whether an entry of the given level is written.
+func (g *levelGate) Sample(level zerolog.Level) bool {
+	state := g.state.Load()
+	if level < g.effectiveLevel(state) {
+		return false
+	}
+	return state.sampling.keep(level)
+}
+
+// outputPolicy returns the current masking policy of the output.
+func (g *levelGate) outputPolicy() *maskingPolicy {
+	return g.state.Load().outputPolicy
+}
+
+// sampler keeps one entry out of every at or below a level.
+type sampler struct {
+	every uint32
+	level zerolog.Level
+	count atomic.Uint32
+}
+
+// newSampler returns the sampler of the config, nil when every entry is written.
+func newSampler(config SamplingConfig) (*sampler, error) {
+	if config.Every <= 1 {
+		return nil, nil
+	}
+	level := zerolog.InfoLevel
+	if config.Level != "" {
+		var err error
+		if level, err = zerolog.ParseLevel(config.Level); err != nil {
+			return nil, err
+		}
+	}
+	return &sampler{every: config.Every, level: level}, nil
+}
+
+// keep reports whether an entry of the given level is kept, always keeping the first entry of every.
+func (s *sampler) keep(level zerolog.Level) bool {
+	if s == nil || level > s.level {
+		return true
+	}
+	return (s.count.Add(1)-1)%s.every == 0
+}
+
+// effectiveLevel returns the override of the caller package, the override of the AppName, or the level of the
+// state, in that order.
+func (g *levelGate) effectiveLevel(state *loggerState) zerolog.Level {
+	if o := overrides.Load(); o != nil {
+		if len(o.packages) > 0 {
+			if level, ok := packageLevel(o.packages, callerPackage()); ok {
+				return level
+			}
+		}
+		if level, ok := o.apps[g.appName]; ok {
+			return level
+		}
+	}
+	return state.level
+}
+
+// SetLevel changes the level of the Logger while it is in use.
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := parseLevelStrict(level)
+	if err != nil {
+		return err
+	}
+	for {
+		current := l.levels.state.Load()
+		next := *current
+		next.level = parsed
+		if l.levels.state.CompareAndSwap(current, &next) {
+			return nil
+		}
+	}
+}
+
+// GetLevel returns the current level of the Logger, without the overrides.
+func (l *Logger) GetLevel() zerolog.Level {
+	return l.levels.state.Load().level
+}
+
+// parseLevelStrict parses a level, rejecting the empty one.
+func parseLevelStrict(level string) (zerolog.Level, error) {
+	if level == "" {
+		return zerolog.NoLevel, errors.New("zlogs: empty level")
+	}
+	return zerolog.ParseLevel(level)
+}
+
+// packageLevel returns the override of the longest package matching pkg, itself or one of its parents.
+func packageLevel(packages map[string]zerolog.Level, pkg string) (zerolog.Level, bool) {
+	for pkg != "" {
+		if level, ok := packages[pkg]; ok {
+			return level, true
+		}
+		slash := strings.LastIndex(pkg, "/")
+		if slash < 0 {
+			break
+		}
+		pkg = pkg[:slash]
+	}
+	return zerolog.NoLevel, false
+}
+
+// findCallerPackage walks up the stack past the frames of zerolog and of this package and returns the import path of
+// the first other package.
+func findCallerPackage() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		pkg := packageOf(frame.Function)
+		if pkg != packagePath && pkg != "github.com/rs/zerolog" && pkg != "github.com/rs/zerolog/log" {
+			return pkg
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// packageOf returns the import path of the package of a function name such as "github.com/a/b.(*T).Method".
+func packageOf(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return function
+	}
+	return function[:slash+1+dot]
+}
+
+// levelNames returns the names of the levels of a map.
+func levelNames(levels map[string]zerolog.Level) map[string]string {
+	names := make(map[string]string, len(levels))
+	for key, level := range levels {
+		names[key] = level.String()
+	}
+	return names
+}
@@ -0,0 +1,151 @@
+package zlogs
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// resetOverrides removes every level override at the end of a test.
+func resetOverrides(t *testing.T) {
+	t.Cleanup(func() { overrides.Store(nil) })
+}
+
+// TestSetLevel verifies that the level of a Logger changes while it is in use, without a data race.
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{Level: "info", Outputs: []OutputConfig{{Writer: zerolog.SyncWriter(&buf)}}})
+	logger.Event(logger.Debug()).Msg("hidden")
+
+	if err := logger.SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Event(logger.Debug()).Msg("visible")
+		}()
+	}
+	wg.Wait()
+
+	if got := buf.String(); strings.Contains(got, "hidden") || strings.Count(got, "visible") != 4 {
+		t.Errorf("unexpected entries %q", got)
+	}
+	if logger.GetLevel() != zerolog.DebugLevel {
+		t.Errorf("got level %v", logger.GetLevel())
+	}
+	if err := logger.SetLevel("verbose"); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+	if err := logger.SetLevel(""); err == nil {
+		t.Error("expected an error for an empty level")
+	}
+}
+
+// TestDisableSampling verifies that the level still filters the entries when zerolog sampling is disabled, which
+// skips the levelGate.
+func TestDisableSampling(t *testing.T) {
+	zerolog.DisableSampling(true)
+	t.Cleanup(func() { zerolog.DisableSampling(false) })
+
+	var buf bytes.Buffer
+	logger := New(&Config{Level: "info", Outputs: []OutputConfig{{Writer: &buf}}})
+	logger.Event(logger.Debug()).Msg("hidden")
+	logger.Event(logger.Info()).Msg("visible")
+
+	if got := buf.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "visible") {
+		t.Errorf("unexpected entries %q", got)
+	}
+}
+
+// TestAppLevel verifies that an AppName override applies to the loggers of the application only.
+func TestAppLevel(t *testing.T) {
+	resetOverrides(t)
+	var orders, payments bytes.Buffer
+	ordersLogger := New(&Config{AppName: "orders", Level: "info", Outputs: []OutputConfig{{Writer: &orders}}})
+	paymentsLogger := New(&Config{AppName: "payments", Level: "info", Outputs: []OutputConfig{{Writer: &payments}}})
+
+	if err := SetAppLevel("orders", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	ordersLogger.Event(ordersLogger.Debug()).Msg("orders debug")
+	paymentsLogger.Event(paymentsLogger.Debug()).Msg("payments debug")
+	if orders.Len() == 0 || payments.Len() != 0 {
+		t.Errorf("expected the override on orders only, got %q and %q", orders.String(), payments.String())
+	}
+	if got := AppLevels(); got["orders"] != "debug" {
+		t.Errorf("got %v", got)
+	}
+
+	if err := SetAppLevel("orders", ""); err != nil {
+		t.Fatal(err)
+	}
+	if overrides.Load() != nil {
+		t.Error("expected no override left")
+	}
+}
+
+// TestPackageLevel verifies that a package override applies to the entries logged from the package or its
+// sub-packages, before the AppName override.
+func TestPackageLevel(t *testing.T) {
+	resetOverrides(t)
+	defer func(find func() string) { callerPackage = find }(callerPackage)
+	callerPackage = func() string { return "github.com/acme/orders/repository" }
+
+	var buf bytes.Buffer
+	logger := New(&Config{AppName: "orders", Level: "info", Outputs: []OutputConfig{{Writer: &buf}}})
+	_ = SetAppLevel("orders", "error")
+	_ = SetPackageLevel("github.com/acme/orders", "debug")
+	logger.Event(logger.Debug()).Msg("from the repository")
+	if !strings.Contains(buf.String(), "from the repository") {
+		t.Errorf("expected the package override to apply, got %q", buf.String())
+	}
+
+	callerPackage = func() string { return "github.com/acme/ordersapi" }
+	buf.Reset()
+	logger.Event(logger.Warn()).Msg("from another package")
+	if buf.Len() != 0 {
+		t.Errorf("expected the AppName override to apply, got %q", buf.String())
+	}
+}
+
+// TestFindCallerPackage verifies that the caller package skips the frames of zerolog and of this package.
+func TestFindCallerPackage(t *testing.T) {
+	if got := findCallerPackage(); got != "testing" {
+		t.Errorf("expected the testing package past this package, got %q", got)
+	}
+	cases := map[string]string{
+		"github.com/rs/zerolog.(*Logger).Info": "github.com/rs/zerolog",
+		"github.com/a/b.c.func1":               "github.com/a/b",
+		"main.main":                            "main",
+	}
+	for function, want := range cases {
+		if got := packageOf(function); got != want {
+			t.Errorf("packageOf(%q) = %q, want %q", function, got, want)
+		}
+	}
+}
+
+// TestSampling verifies that the sampling keeps one entry out of every at or below its level only.
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{Level: "debug", Sampling: SamplingConfig{Every: 3}, Outputs: []OutputConfig{{Writer: &buf}}})
+	for i := 0; i < 6; i++ {
+		logger.Event(logger.Info()).Msg("sampled")
+		logger.Event(logger.Warn()).Msg("kept")
+	}
+	if got := buf.String(); strings.Count(got, "sampled") != 2 || strings.Count(got, "kept") != 6 {
+		t.Errorf("unexpected entries %q", got)
+	}
+	if _, err := newSampler(SamplingConfig{Every: 2, Level: "verbose"}); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+	if s, _ := newSampler(SamplingConfig{Every: 1}); s != nil {
+		t.Error("expected no sampler when every entry is kept")
+	}
+}
@@ -0,0 +1,114 @@
+package zlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// logfmtWriter is an io.Writer turning the JSON lines of the entries into logfmt lines. The nested fields are
+// flattened into dotted keys such as "data.password" or "items[0].name", in the order of the JSON line, so that the
+// field names and the masked values are the same as in the JSON format.
+type logfmtWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// newLogfmtWriter returns a logfmtWriter writing to out.
+func newLogfmtWriter(out io.Writer) *logfmtWriter {
+	return &logfmtWriter{out: out}
+}
+
+// Write encodes every line of p as logfmt and writes them to the underlying writer.
+func (w *logfmtWriter) Write(p []byte) (int, error) {
+	encoded := mapLines(p, encodeLogfmt)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.out.Write(encoded); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// encodeLogfmt encodes a JSON line as logfmt, or returns it untouched when it is not a JSON object.
+func encodeLogfmt(line []byte) []byte {
+	fields, err := decodeFields(line)
+	if err != nil {
+		return line
+	}
+	var buf bytes.Buffer
+	for _, field := range fields {
+		appendLogfmt(&buf, fieldPath{field.key}, field.raw)
+	}
+	return buf.Bytes()
+}
+
+// appendLogfmt appends the key=value pairs of a JSON value found under the path, flattening objects and arrays.
+func appendLogfmt(buf *bytes.Buffer, path fieldPath, raw json.RawMessage) {
+	switch raw[0] {
+	case '{':
+		if fields, err := decodeFields(raw); err == nil && len(fields) > 0 {
+			for _, field := range fields {
+				appendLogfmt(buf, append(path[:len(path):len(path)], field.key), field.raw)
+			}
+			return
+		}
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err == nil && len(items) > 0 {
+			for i, item := range items {
+				appendLogfmt(buf, append(path[:len(path):len(path)], "["+strconv.Itoa(i)+"]"), item)
+			}
+			return
+		}
+	}
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(logfmtKey(path.String()))
+	buf.WriteByte('=')
+	buf.WriteString(logfmtValue(raw))
+}
+
+// logfmtKey replaces the characters that logfmt does not allow in a key.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtValue returns a scalar JSON value as a logfmt value, quoting the strings that need it.
+func logfmtValue(raw json.RawMessage) string {
+	var s string
+	if raw[0] != '"' || json.Unmarshal(raw, &s) != nil {
+		return string(raw)
+	}
+	if needsQuoting(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// needsQuoting reports whether a logfmt value must be quoted: when empty, or when it contains a space, a quote,
+// an equal sign or a control character.
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || r == '\\' || unicode.IsSpace(r) || unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return true
+		}
+	}
+	return false
+}
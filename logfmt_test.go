@@ -0,0 +1,55 @@
+package zlogs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestLogfmtFormat verifies that a logger in logfmt format flattens and masks the nested fields.
+func TestLogfmtFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{
+		Level:   "debug",
+		Format:  FormatLogfmt,
+		Masking: MaskingConfig{Enabled: true},
+		Outputs: []OutputConfig{{Writer: &buf}},
+	})
+	logger.Event(logger.Info()).
+		WithFields(map[string]interface{}{"data": map[string]interface{}{"password": "P@ss", "user": "john"}}).
+		Msg("user logged in")
+
+	got := buf.String()
+	for _, want := range []string{"severity=info", "timestamp=", "data.password=***", "data.user=john", `message="user logged in"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+	if strings.Contains(got, "{") {
+		t.Errorf("expected no JSON left, got %q", got)
+	}
+}
+
+// TestEncodeLogfmt verifies the flattening of the nested values and the quoting of the values.
+func TestEncodeLogfmt(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"Scalars", `{"n":1,"ok":true,"nil":null}`, `n=1 ok=true nil=null`},
+		{"Quoting", `{"a":"two words","b":"","c":"x=y","d":"say \"hi\"","e":"line\nbreak"}`,
+			`a="two words" b="" c="x=y" d="say \"hi\"" e="line\nbreak"`},
+		{"Nested", `{"a":{"b":{"c":1}},"items":[{"id":1},{"id":2}]}`, `a.b.c=1 items[0].id=1 items[1].id=2`},
+		{"EmptyContainers", `{"a":{},"b":[]}`, `a={} b=[]`},
+		{"Keys", `{"x y":1,"a=b":2}`, `x_y=1 a_b=2`},
+		{"NotJSON", `plain text`, `plain text`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(encodeLogfmt([]byte(tc.line))); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,10 @@
 package zlogs
 
 import (
-	"encoding/json"
-	"os"
+	"context"
+	"fmt"
+	"io"
 	"reflect"
-	"strings"
 
 	"github.com/rs/zerolog"
 	zlog "github.com/rs/zerolog/log"
@@ -18,23 +18,92 @@ type (
 	Logger struct {
 		*zerolog.Logger
 		Masking MaskingConfig
+		// policy is the masking policy of the events. The levels hold the current one, replaced by Reload, and the
+		// policy of a Logger returned by Event is fixed for its entry.
+		policy *maskingPolicy
+		output *output
+		// fieldNames are the names of the timestamp, level and message fields of the entries.
+		fieldNames FieldNames
+		levels     *levelGate
+		// config is the configuration the Logger was created with, from which NewGORMLogger builds its logger.
+		config Config
 	}
 	Config struct {
 		AppName      string
 		Level        string
 		Masking      MaskingConfig
 		CallerEnable bool
+		// Outputs lists the writers receiving the entries, defaulting to os.Stdout.
+		Outputs []OutputConfig
+		// FieldNames renames the timestamp, level and message fields, "timestamp", "severity" and "message" by default.
+		FieldNames FieldNames
+		// Profile adapts the field names and values to a log management platform, e.g. ProfileGCP.
+		Profile Profile
+		// ProjectID is the Google Cloud project of the traces written by ProfileGCP. Without it, the trace IDs are not
+		// linked to Cloud Trace and stay in trace_id.
+		ProjectID string
+		// Format is the format of the outputs. It defaults to FormatConsole when the output is a terminal and to
+		// FormatJSON otherwise.
+		Format Format
+		// Async writes the entries from a background goroutine; call Logger.Close on shutdown to write the last ones.
+		Async AsyncConfig
+		// Sampling writes only a fraction of the verbose entries.
+		Sampling SamplingConfig
+		// ContextExtractors add fields from the context of every entry, DefaultContextExtractors when nil.
+		ContextExtractors []ContextExtractor
+	}
+	OutputConfig struct {
+		// Writer receives the entries. When nil, Path selects OutputStdout, OutputStderr or a file path.
+		Writer io.Writer
+		Path   string
+		// Level is the minimum level written to this output, on top of the logger level.
+		Level string
+		// Rotation rotates the file of the output by size and age. It is ignored for a Writer or a standard stream.
+		Rotation RotationConfig
+	}
+	FieldNames struct {
+		Timestamp string
+		Level     string
+		Message   string
 	}
 	MaskingConfig struct {
-		Enabled         bool
+		Enabled bool
+		// SensitiveFields lists extra key names masked at any depth, or path selectors such as "customer.name",
+		// "items[*].card.number" and "**.password". A selector starting with "!" excludes the matching fields.
 		SensitiveFields []string
+		// Detectors lists the built-in value detectors (see DefaultDetectors), applied in the given order.
+		Detectors []string
+		// Patterns maps a detector name to a custom regular expression whose matches are masked.
+		Patterns map[string]string
+		// Strategies maps a field or detector name to the strategy used to mask it, instead of redacting it.
+		// A field with a strategy is sensitive even if it is not listed in SensitiveFields.
+		Strategies map[string]MaskStrategy
+		// HashSecret keys the MaskHash strategy with HMAC-SHA256.
+		HashSecret string
+		// TokenVault stores the values replaced by the MaskTokenize strategy, e.g. a MemoryVault or a FileVault.
+		TokenVault TokenVault
+		// AtOutput masks every entry at the output writer, whichever API produced it, instead of in the events.
+		// The events then only apply the zlog struct tags.
+		AtOutput bool
+		// Observer is notified of every masked field and detected value, e.g. a MaskingCounter.
+		Observer MaskingObserver
+		// DryRun keeps the values untouched and lists the fields that would have been masked in masked_fields.
+		DryRun bool
+	}
+	SamplingConfig struct {
+		// Every writes one entry out of Every at or below Level; 0 and 1 write them all.
+		Every uint32
+		// Level is the highest sampled level, "info" by default. The entries above it are always written.
+		Level string
 	}
 	Event struct {
 		*zerolog.Event
+		logger *Logger
 	}
 )
 
-// sensitiveFields contains a set of keys that are considered sensitive and need to be masked in logs or data processing.
+// sensitiveFields contains the default set of keys that are considered sensitive and need to be masked in logs or data processing.
+// It is never modified; each Logger copies it into its own masking policy.
 var (
 	sensitiveFields = map[string]struct{}{
 		"name": {}, "firstname": {}, "lastname": {}, "cardno": {}, "passport": {},
@@ -43,102 +112,201 @@ var (
 		"authorization": {}, "x-authorization": {},
 	}
 	appNameKey = "appName"
-	logger     *Logger
+	// defaultFieldNames are the field names of the entries when Config.FieldNames leaves them empty.
+	defaultFieldNames = FieldNames{Timestamp: "timestamp", Level: "severity", Message: "message"}
 )
 
-// newStandardLogger initializes a standard Logger instance with default configuration for level "debug" and masking disabled.
+// newStandardLogger initializes a standard Logger instance with default configuration for level "debug" and masking enabled.
 func newStandardLogger() *Logger {
 	defaultConfig := &Config{
 		Level: "debug",
 		Masking: MaskingConfig{
-			Enabled: false,
+			Enabled: true,
 		},
 	}
 	return newLogger(defaultConfig)
 }
 
-// newLogger initializes the global logger instance with the provided configuration.
+// newLogger initializes a Logger instance with the provided configuration, without touching any global state.
 func newLogger(config *Config) *Logger {
-	zlog.Logger = initZerologLogger(config)
-	logger = &Logger{
-		Logger:  &zlog.Logger,
-		Masking: config.Masking,
+	levels := newLevelGate(config)
+	zerologLogger, out := initZerologLogger(config, levels)
+	if out.masking != nil {
+		out.masking.policy = levels.outputPolicy
 	}
+	return &Logger{
+		Logger:     &zerologLogger,
+		Masking:    config.Masking,
+		policy:     levels.state.Load().policy,
+		output:     out,
+		fieldNames: config.FieldNames.withDefaults(),
+		levels:     levels,
+		config:     *config,
+	}
+}
+
+// New creates a standalone Logger with its own masking policy, field names, level and output, leaving the
+// package-level logger and the zerolog globals untouched.
+func New(config *Config) *Logger {
+	return newLogger(config)
+}
+
+// SetAsGlobal makes the Logger the package-level logger and the global zerolog logger: it replaces zlog.Logger and
+// sets the zerolog field names to the ones of the Logger. The zerolog global level is set to trace, so that the
+// entries are filtered by the level of the Logger, which SetLevel, Reload and the overrides can still change.
+// This affects every other zerolog user of the binary.
+func (l *Logger) SetAsGlobal() {
+	zerolog.TimestampFieldName = l.fieldNames.Timestamp
+	zerolog.LevelFieldName = l.fieldNames.Level
+	zerolog.MessageFieldName = l.fieldNames.Message
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	zlog.Logger = *l.Logger
+	std = l
+}
 
-	return logger
+// Flush waits until the entries buffered by an asynchronous output are written, or until the context is done.
+func (l *Logger) Flush(ctx context.Context) error {
+	return l.output.flush(ctx)
 }
 
-// initZerologLogger initializes and configures a zerolog.Logger instance based on the provided configuration.
-func initZerologLogger(config *Config) zerolog.Logger {
-	zerolog.TimestampFieldName = "timestamp"
-	zerolog.LevelFieldName = "severity"
-	zerolog.MessageFieldName = "message"
-	if level, err := zerolog.ParseLevel(config.Level); err != nil {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	} else {
-		zerolog.SetGlobalLevel(level)
+// Close writes the entries buffered by an asynchronous output and closes the files opened for the outputs.
+// The Logger must not be used afterwards.
+func (l *Logger) Close() error {
+	return l.output.close()
+}
+
+// Event wraps a zerolog event so that WithField and WithFields apply this Logger's masking policy.
+func (l *Logger) Event(e *zerolog.Event) *Event {
+	if entry := l.levels.state.Load().policy.forEntry(); entry != l.policy {
+		scoped := *l
+		scoped.policy = entry
+		return &Event{Event: e, logger: &scoped}
 	}
-	for _, field := range config.Masking.SensitiveFields {
-		sensitiveFields[strings.ToLower(field)] = struct{}{}
+	return &Event{Event: e, logger: l}
+}
+
+// initZerologLogger initializes and configures a zerolog.Logger instance based on the provided configuration,
+// filtered by the level gate and masking the context fields with its current policy, returning it with its output.
+func initZerologLogger(config *Config, levels *levelGate) (zerolog.Logger, *output) {
+	out := newOutput(config)
+	if out.async != nil {
+		reporter := newZerologLogger(config, out.async.out, true, nil)
+		out.async.report = func(dropped uint64) {
+			reporter.Warn().Uint64("dropped_entries", dropped).Msg("log entries dropped by the asynchronous output")
+		}
 	}
-	return zerolog.New(os.Stdout).Hook(&InitHook{
+	return newZerologLogger(config, out.writer, config.CallerEnable, levels), out
+}
+
+// newZerologLogger creates a zerolog.Logger writing to w, with the entry data of InitHook and the timestamp under
+// the field name of the config. The entries are filtered by the level gate, or by the level of the config when
+// there is no gate. The context fields are masked with the current policy of the gate, when there is one.
+func newZerologLogger(config *Config, w io.Writer, disableCaller bool, levels *levelGate) zerolog.Logger {
+	logger := zerolog.New(w).Hook(&InitHook{
 		AppName:       config.AppName,
-		DisableCaller: config.CallerEnable,
-	}).With().Timestamp().Logger().Level(zerolog.GlobalLevel())
+		DisableCaller: disableCaller,
+		Extractors:    config.ContextExtractors,
+		levels:        levels,
+	}).Hook(timestampHook(config.FieldNames.withDefaults().Timestamp))
+	if levels == nil {
+		return logger.Level(parseLevel(config.Level))
+	}
+	return logger.Level(zerolog.TraceLevel).Sample(levels)
 }
 
-// maskFields processes a map to mask sensitive fields based on the Logger configuration.
-func (l *Logger) maskFields(value map[string]interface{}) map[string]interface{} {
+// parseLevel parses the level of a config, defaulting to debug when it is empty or invalid.
+func parseLevel(level string) zerolog.Level {
+	parsed, err := zerolog.ParseLevel(level)
+	if err != nil || level == "" {
+		return zerolog.DebugLevel
+	}
+	return parsed
+}
+
+// maskFields processes a map found under the given path to mask sensitive fields based on the Logger configuration.
+func (l *Logger) maskFields(path fieldPath, value map[string]interface{}) map[string]interface{} {
+	if !l.policy.enabled {
+		return value
+	}
 	newData := make(map[string]interface{}, len(value))
 	for key, fieldValue := range value {
-		newData = l.valueMasking(newData, key, fieldValue)
+		newData = l.valueMasking(path, newData, key, fieldValue)
 	}
 	return newData
 }
 
 // valueMasking masks sensitive fields in a nested map or array structure, otherwise it retains the original field value.
-func (l *Logger) valueMasking(result map[string]interface{}, key string, value interface{}) map[string]interface{} {
-	if isSensitiveField(key) {
-		result[key] = redactedValue
+// The field is matched by its key name and by its full path, starting with the parent path. Typed maps, slices,
+// arrays, pointers and structs are walked by reflection.
+func (l *Logger) valueMasking(parent fieldPath, result map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	if mask, sensitive := l.policy.match(parent, key); sensitive {
+		if masked, keep := maskValue(mask, value); keep {
+			result[key] = masked
+		}
 		return result
 	}
 	switch v := value.(type) {
 	case map[string]interface{}:
-		result[key] = l.maskFields(v)
+		result[key] = l.maskFields(l.policy.child(parent, key), v)
 	case []interface{}:
-		result[key] = l.maskArrayFields(v)
+		result[key] = l.maskArrayFields(l.policy.child(parent, key), v)
+	case string:
+		result[key] = l.policy.maskString(l.policy.child(parent, key), v)
 	default:
-		result[key] = v
+		result[key] = l.policy.plainValue(l.policy.child(parent, key), reflect.ValueOf(v), nil)
 	}
 	return result
 }
 
-// isSensitiveField checks if a given field name is considered sensitive based on a predefined list of sensitive fields.
-func isSensitiveField(field string) bool {
-	_, exists := sensitiveFields[strings.ToLower(field)]
-	return exists
+// maskMessage applies the Logger's masking policy to a log message.
+func (l *Logger) maskMessage(msg string) string {
+	return l.policy.maskMessage(msg)
+}
+
+// isSensitiveField checks if a given field name is considered sensitive by the Logger's masking policy.
+func (l *Logger) isSensitiveField(field string) bool {
+	return l.policy.isSensitive(field)
 }
 
-// maskArrayFields iterates over an array of interface values and applies field masking to any map elements.
-func (l *Logger) maskArrayFields(array []interface{}) []interface{} {
+// maskArrayFields returns a copy of an array found under the given path, applying field masking to any map or nested
+// array element and value detection to any string element. The array itself is left untouched, as it may be shared,
+// e.g. by the fields of a context.
+func (l *Logger) maskArrayFields(path fieldPath, array []interface{}) []interface{} {
+	masked := make([]interface{}, len(array))
 	for i, value := range array {
-		if valueMap, ok := value.(map[string]interface{}); ok {
-			array[i] = l.maskFields(valueMap)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			masked[i] = l.maskFields(l.policy.childIndex(path, i), v)
+		case []interface{}:
+			masked[i] = l.maskArrayFields(l.policy.childIndex(path, i), v)
+		case string:
+			masked[i] = l.policy.maskString(l.policy.childIndex(path, i), v)
+		default:
+			masked[i] = l.policy.plainValue(l.policy.childIndex(path, i), reflect.ValueOf(v), nil)
 		}
 	}
-	return array
+	return masked
 }
 
-// WithField adds a key-value pair to the event, converting and masking the value if necessary, and returns the updated event.
+// WithField adds a key-value pair to the event, masking the value if necessary, and returns the updated event.
+// Non-primitive values are written field by field into the event, honouring their zlog struct tags. Nothing is masked
+// when the event is disabled, e.g. by the level, so that the masking observer only sees the written fields.
 func (e *Event) WithField(key string, value interface{}) *Event {
+	if e.Event == nil {
+		return e
+	}
 	if !isPrimitiveType(value) {
-		value = logger.ConvertStructToFields(value)
+		e.getLogger().policy.encodeField(e.Event, nil, key, reflect.ValueOf(value), nil)
+		return e
 	}
-	return e.WithFields(logger.maskFields(map[string]interface{}{key: value}))
+	return e.WithFields(map[string]interface{}{key: value})
 }
 
 // isPrimitiveType determines if the given value is of a primitive Go type that does not require conversion.
 func isPrimitiveType(value interface{}) bool {
+	if value == nil {
+		return false
+	}
 	switch reflect.TypeOf(value).Kind() {
 	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
@@ -151,23 +319,83 @@ func isPrimitiveType(value interface{}) bool {
 
 // WithFields adds multiple fields to the event, masking sensitive data, and returns the updated event.
 func (e *Event) WithFields(fields map[string]interface{}) *Event {
-	fields = logger.maskFields(fields)
+	if e.Event == nil {
+		return e
+	}
+	fields = e.getLogger().maskFields(nil, fields)
 	for key, fieldValue := range fields {
 		e.Event = e.Event.Interface(key, fieldValue)
 	}
 	return e
 }
 
+// Msg masks the message with the Logger's masking policy and sends the event.
+func (e *Event) Msg(msg string) {
+	e.send(msg)
+}
+
+// Msgf masks the structured arguments, formats the message, masks it with the Logger's masking policy and sends the event.
+// Nothing is formatted when the event is disabled, e.g. by the level.
+func (e *Event) Msgf(format string, v ...interface{}) {
+	if e.Event == nil {
+		return
+	}
+	e.send(fmt.Sprintf(format, e.getLogger().policy.maskArgs(v)...))
+}
+
+// MsgFunc builds the message with createMsg, masks it with the Logger's masking policy and sends the event.
+func (e *Event) MsgFunc(createMsg func() string) {
+	if e.Event == nil {
+		return
+	}
+	e.send(createMsg())
+}
+
+// send masks and emits the message, skipping the two extra frames of the Event wrappers in the caller information.
+// It is only reached through Msg, Msgf and MsgFunc so that the number of frames stays the same. A disabled event
+// is dropped before any masking.
+func (e *Event) send(msg string) {
+	if e.Event == nil {
+		return
+	}
+	ctx := e.GetCtx()
+	skip := defaultCallerSkip
+	if s, ok := ctx.Value(CallerSkip).(int); ok {
+		skip = s
+	}
+	logger := e.getLogger()
+	msg = logger.maskMessage(msg)
+	if logger.policy.trace != nil && ctx.Value(contextAddedKey) == nil {
+		// In dry-run mode, the fields of the context are added here rather than by InitHook, so that masked_fields
+		// lists them as well.
+		addContext(e.Event, ctx, logger.config.ContextExtractors, logger.policy)
+		ctx = context.WithValue(ctx, contextAddedKey, true)
+	}
+	if maskedFields := logger.policy.trace.list(); len(maskedFields) > 0 {
+		e.Event.Strs(maskedFieldsKey, maskedFields)
+	}
+	e.Event.Ctx(AddCallerSkip(ctx, skip+2)).Msg(msg)
+}
+
+// getLogger returns the Logger that created the event, falling back to the standard logger.
+func (e *Event) getLogger() *Logger {
+	if e.logger != nil {
+		return e.logger
+	}
+	return std
+}
+
 // WithError attaches an error to the Event and returns the modified Event.
 func (e *Event) WithError(err error) *Event {
 	e.Event = e.Err(err)
 	return e
 }
 
-// ConvertStructToFields converts a struct to a map of string keys and interface{} values using JSON marshaling.
+// ConvertStructToFields converts a struct to a map of string keys and interface{} values, keeping the field types
+// and omitting the fields tagged with `zlog:"omit"`. It returns an empty map for values that are not structs or maps.
 func (l *Logger) ConvertStructToFields(v any) map[string]interface{} {
-	data, _ := json.Marshal(v)
-	fields := make(map[string]interface{})
-	_ = json.Unmarshal(data, &fields)
-	return fields
+	if fields, ok := unmaskedPolicy.plainValue(nil, reflect.ValueOf(v), nil).(map[string]interface{}); ok {
+		return fields
+	}
+	return make(map[string]interface{})
 }
@@ -59,7 +59,7 @@ func TestMaskingFunctions(t *testing.T) {
 	logger := newLogger(&Config{
 		Level: "debug",
 		Masking: MaskingConfig{
-			Enabled:         false,
+			Enabled:         true,
 			SensitiveFields: []string{"cc_number"},
 		},
 	})
@@ -76,7 +76,7 @@ func TestMaskingFunctions(t *testing.T) {
 		}
 		for _, tc := range cases {
 			t.Run(tc.name, func(t *testing.T) {
-				got := logger.maskFields(tc.input)
+				got := logger.maskFields(nil, tc.input)
 				if !reflect.DeepEqual(got, tc.want) {
 					t.Errorf("got %v, want %v", got, tc.want)
 				}
@@ -98,7 +98,7 @@ func TestMaskingFunctions(t *testing.T) {
 		}
 		for _, tc := range cases {
 			t.Run(tc.name, func(t *testing.T) {
-				got := logger.valueMasking(tc.result, tc.key, tc.value)
+				got := logger.valueMasking(nil, tc.result, tc.key, tc.value)
 				if !reflect.DeepEqual(got, tc.want) {
 					t.Errorf("got %v, want %v", got, tc.want)
 				}
@@ -118,7 +118,7 @@ func TestMaskingFunctions(t *testing.T) {
 		}
 		for _, tc := range cases {
 			t.Run(tc.name, func(t *testing.T) {
-				got := logger.maskArrayFields(tc.input)
+				got := logger.maskArrayFields(nil, tc.input)
 				if !reflect.DeepEqual(got, tc.want) {
 					t.Errorf("got %v, want %v", got, tc.want)
 				}
@@ -149,11 +149,79 @@ func TestMaskingFunctions(t *testing.T) {
 	})
 }
 
+// TestMaskingPolicy verifies that each Logger owns its masking policy and that a disabled policy leaves values untouched.
+func TestMaskingPolicy(t *testing.T) {
+	masked := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: true, SensitiveFields: []string{"audit_id"}}})
+	unmasked := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: false}})
+	other := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: true}})
+
+	cases := []struct {
+		name   string
+		logger *Logger
+		want   map[string]interface{}
+	}{
+		{"MaskedWithCustomField", masked, map[string]interface{}{"password": redactedValue, "audit_id": redactedValue}},
+		{"DisabledKeepsValues", unmasked, map[string]interface{}{"password": "secret", "audit_id": "a-1"}},
+		{"CustomFieldNotShared", other, map[string]interface{}{"password": redactedValue, "audit_id": "a-1"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.logger.maskFields(nil, map[string]interface{}{"password": "secret", "audit_id": "a-1"})
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestMaskingShapes verifies that masking walks typed maps, slices, arrays, pointers and nested arrays.
+func TestMaskingShapes(t *testing.T) {
+	type account struct {
+		Name    string `json:"name"`
+		Balance int    `json:"balance"`
+	}
+	logger := New(&Config{
+		Level: "debug",
+		Masking: MaskingConfig{
+			Enabled:         true,
+			SensitiveFields: []string{"emails"},
+			Detectors:       []string{DetectorEmail},
+		},
+	})
+	password := "secret"
+
+	cases := []struct {
+		name  string
+		input map[string]interface{}
+		want  map[string]interface{}
+	}{
+		{"TypedStringMap", map[string]interface{}{"fig2": map[string]string{"password": "2111", "pin": "1"}}, map[string]interface{}{"fig2": map[string]interface{}{"password": redactedValue, "pin": "1"}}},
+		{"SliceOfMaps", map[string]interface{}{"users": []map[string]any{{"password": "x"}, {"id": 1}}}, map[string]interface{}{"users": []interface{}{map[string]interface{}{"password": redactedValue}, map[string]interface{}{"id": 1}}}},
+		{"PrimitiveSliceUnderSensitiveKey", map[string]interface{}{"emails": []string{"a@b.co", "c@d.co"}}, map[string]interface{}{"emails": []interface{}{redactedValue, redactedValue}}},
+		{"PrimitiveArrayUnderSensitiveKey", map[string]interface{}{"password": [2]int{1, 2}}, map[string]interface{}{"password": []interface{}{redactedValue, redactedValue}}},
+		{"StringSliceDetected", map[string]interface{}{"contacts": []string{"a@b.co", "phone"}}, map[string]interface{}{"contacts": []interface{}{redactedValue, "phone"}}},
+		{"NestedArrays", map[string]interface{}{"matrix": []interface{}{[]interface{}{map[string]interface{}{"password": "x"}}, []any{map[string]string{"cvc": "1"}}}}, map[string]interface{}{"matrix": []interface{}{[]interface{}{map[string]interface{}{"password": redactedValue}}, []interface{}{map[string]interface{}{"cvc": redactedValue}}}}},
+		{"Pointer", map[string]interface{}{"detail": &map[string]interface{}{"password": &password}}, map[string]interface{}{"detail": map[string]interface{}{"password": redactedValue}}},
+		{"SliceOfStructs", map[string]interface{}{"accounts": []account{{Name: "John", Balance: 10}}}, map[string]interface{}{"accounts": []interface{}{map[string]interface{}{"name": redactedValue, "balance": 10}}}},
+		{"NonStringKeyMap", map[string]interface{}{"codes": map[int]string{1: "a@b.co"}}, map[string]interface{}{"codes": map[string]interface{}{"1": redactedValue}}},
+		{"NilPointer", map[string]interface{}{"detail": (*account)(nil)}, map[string]interface{}{"detail": nil}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := logger.maskFields(nil, tc.input)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
 // TestEventFunctions tests functionalities of Event methods such as WithField, WithFields, and WithError.
 func TestEventFunctions(t *testing.T) {
-	logger = newStandardLogger()
+	logger := newStandardLogger()
 	event := &Event{
-		Event: zlog.Info(),
+		Event:  zlog.Info(),
+		logger: logger,
 	}
 
 	t.Run("testWithField", func(t *testing.T) {
@@ -255,7 +323,7 @@ func TestLoggerFunctions(t *testing.T) {
 		}
 		for _, tc := range cases {
 			t.Run(tc.name, func(t *testing.T) {
-				zerologLogger := initZerologLogger(tc.config)
+				zerologLogger, _ := initZerologLogger(tc.config, nil)
 				if zerologLogger.GetLevel() != tc.level {
 					t.Errorf("expected %v level, got %v", tc.level, zerologLogger.GetLevel())
 				}
@@ -264,7 +332,7 @@ func TestLoggerFunctions(t *testing.T) {
 	})
 
 	t.Run("testIsSensitiveField", func(t *testing.T) {
-		sensitiveFields["password"] = struct{}{}
+		logger := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: true}})
 		cases := []struct {
 			name     string
 			field    string
@@ -275,7 +343,7 @@ func TestLoggerFunctions(t *testing.T) {
 		}
 		for _, tc := range cases {
 			t.Run(tc.name, func(t *testing.T) {
-				if got := isSensitiveField(tc.field); got != tc.expected {
+				if got := logger.isSensitiveField(tc.field); got != tc.expected {
 					t.Errorf("expected %v, got %v", tc.expected, got)
 				}
 			})
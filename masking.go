@@ -0,0 +1,205 @@
+package zlogs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// maskingPolicy is the immutable set of masking rules owned by a single Logger.
+type maskingPolicy struct {
+	enabled       bool
+	tagsEnabled   bool
+	fields        map[string]struct{}
+	selectors     []pathSelector
+	exclusions    []pathSelector
+	detectors     []*valueDetector
+	strategies    map[string]maskFunc
+	hashSecret    string
+	vault         TokenVault
+	tagStrategies *sync.Map
+	observer      MaskingObserver
+	dryRun        bool
+	trace         *maskTrace
+}
+
+// newMaskingPolicy builds a maskingPolicy from the default sensitive fields and the ones listed in the config.
+// Entries that are path selectors are matched against the full path of a field, and the ones starting with "!"
+// exclude the matching fields from masking. Every field, selector or detector given a strategy is masked with it;
+// the others are redacted.
+func newMaskingPolicy(config MaskingConfig) *maskingPolicy {
+	p := &maskingPolicy{
+		enabled:       config.Enabled,
+		tagsEnabled:   config.Enabled,
+		fields:        make(map[string]struct{}, len(sensitiveFields)+len(config.SensitiveFields)),
+		detectors:     newValueDetectors(config),
+		strategies:    make(map[string]maskFunc, len(config.Strategies)),
+		hashSecret:    config.HashSecret,
+		vault:         config.TokenVault,
+		tagStrategies: &sync.Map{},
+		observer:      config.Observer,
+		dryRun:        config.DryRun,
+	}
+	for field := range sensitiveFields {
+		p.fields[field] = struct{}{}
+	}
+	for _, field := range config.SensitiveFields {
+		p.addField(field, nil)
+	}
+	for name, strategy := range config.Strategies {
+		mask := newMaskFunc(strategy, config.HashSecret, config.TokenVault)
+		if isDetectorName(p.detectors, strings.ToLower(name)) {
+			p.strategies[strings.ToLower(name)] = mask
+			continue
+		}
+		p.addField(name, mask)
+	}
+	return p
+}
+
+// newEventPolicy builds the policy applied by the events of a Logger. When masking happens at the output writer,
+// the events only apply the zlog struct tags, which the writer cannot see.
+func newEventPolicy(config MaskingConfig) *maskingPolicy {
+	p := newMaskingPolicy(config)
+	if config.AtOutput {
+		p.enabled = false
+	}
+	return p
+}
+
+// addField registers a sensitive key name or path selector, with an optional strategy.
+func (p *maskingPolicy) addField(field string, mask maskFunc) {
+	if !isPathSelector(field) {
+		p.fields[strings.ToLower(field)] = struct{}{}
+		if mask != nil {
+			p.strategies[strings.ToLower(field)] = mask
+		}
+		return
+	}
+	if exclusion, ok := strings.CutPrefix(field, "!"); ok {
+		p.exclusions = append(p.exclusions, parsePathSelector(exclusion))
+		return
+	}
+	selector := parsePathSelector(field)
+	selector.mask = mask
+	p.selectors = append(p.selectors, selector)
+}
+
+// isDetectorName reports whether name belongs to one of the detectors.
+func isDetectorName(detectors []*valueDetector, name string) bool {
+	for _, detector := range detectors {
+		if strings.ToLower(detector.name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// match decides whether the field named key under the parent path is sensitive, and returns its maskFunc.
+// Exclusions win over any other rule, then path selectors, then key names. The matching rule is reported to the
+// observer; in dry-run mode the field is recorded and reported as not sensitive so that its value is kept.
+func (p *maskingPolicy) match(parent fieldPath, key string) (maskFunc, bool) {
+	if !p.enabled {
+		return nil, false
+	}
+	mask, rule := p.matchRule(parent, key)
+	if rule == "" {
+		return nil, false
+	}
+	if p.observer != nil {
+		p.observer.FieldMasked(rule)
+	}
+	if p.dryRun {
+		p.trace.add(append(parent[:len(parent):len(parent)], key).String())
+		return nil, false
+	}
+	return mask, true
+}
+
+// matchRule returns the maskFunc of the field named key under the parent path and the rule it matched,
+// or an empty rule when the field is not sensitive.
+func (p *maskingPolicy) matchRule(parent fieldPath, key string) (maskFunc, string) {
+	if p.tracksPaths() {
+		path := append(parent[:len(parent):len(parent)], key)
+		for _, exclusion := range p.exclusions {
+			if exclusion.matches(path) {
+				return nil, ""
+			}
+		}
+		for _, selector := range p.selectors {
+			if selector.matches(path) {
+				if selector.mask != nil {
+					return selector.mask, selector.rule
+				}
+				return p.strategyFor(key), selector.rule
+			}
+		}
+	}
+	if _, exists := p.fields[strings.ToLower(key)]; exists {
+		return p.strategyFor(key), strings.ToLower(key)
+	}
+	return nil, ""
+}
+
+// isSensitive checks if a given top-level field name is sensitive for the policy.
+func (p *maskingPolicy) isSensitive(field string) bool {
+	_, sensitive := p.match(nil, field)
+	return sensitive
+}
+
+// strategyFor returns the maskFunc configured for a field or detector name, defaulting to redaction.
+func (p *maskingPolicy) strategyFor(name string) maskFunc {
+	if mask, ok := p.strategies[strings.ToLower(name)]; ok {
+		return mask
+	}
+	return redact
+}
+
+// maskValue masks the value of a sensitive field, dereferencing pointers. Slices and arrays are masked element by
+// element. It returns false when the field must be dropped.
+func maskValue(mask maskFunc, value interface{}) (interface{}, bool) {
+	if s, ok := value.(string); ok {
+		return mask(s)
+	}
+	v := indirect(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return mask(fmt.Sprint(value))
+	}
+	if (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Type().Elem().Kind() == reflect.Uint8 {
+		return mask(fmt.Sprint(v.Interface()))
+	}
+	if _, keep := mask(""); !keep {
+		return nil, false
+	}
+	masked := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if elem, keep := maskValue(mask, interfaceOf(v.Index(i))); keep {
+			masked = append(masked, elem)
+		}
+	}
+	return masked, true
+}
+
+// maskString replaces every value found by the policy's detectors in a string located at path. Each detector
+// finding a value is reported to the observer; in dry-run mode the value is only recorded.
+func (p *maskingPolicy) maskString(path fieldPath, value string) string {
+	for _, detector := range p.detectors {
+		if p.observer == nil && !p.dryRun {
+			value = detector.mask(value, p.strategyFor(detector.name))
+			continue
+		}
+		if !detector.detect(value) {
+			continue
+		}
+		if p.observer != nil {
+			p.observer.ValueDetected(detector.name)
+		}
+		if p.dryRun {
+			p.trace.add(path.String() + ":" + detector.name)
+			continue
+		}
+		value = detector.mask(value, p.strategyFor(detector.name))
+	}
+	return value
+}
@@ -0,0 +1,105 @@
+package zlogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	// jsonFragmentPattern matches a "key": value pair of a JSON fragment embedded in a message.
+	jsonFragmentPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)+)"(\s*:\s*)("(?:[^"\\]|\\.)*"|-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?|true|false)`)
+	// keyValuePattern matches a key=value or key: value pair in a message, including "Bearer <token>" values.
+	keyValuePattern = regexp.MustCompile(`(^|[\s,;&?(\[{])([A-Za-z_][\w.\-]*)(\s*[=:]\s*)((?i:bearer|basic)\s+[^\s,;&)\]}]+|"[^"]*"|'[^']*'|[^\s,;&)\]}]+)`)
+)
+
+// maskMessage masks a rendered log message: every value found by the detectors, then the values of sensitive keys
+// in JSON fragments and key=value pairs. A value whose strategy drops it is redacted, as the message keeps its text.
+func (p *maskingPolicy) maskMessage(msg string) string {
+	if !p.enabled {
+		return msg
+	}
+	msg = p.maskString(fieldPath{zerolog.MessageFieldName}, msg)
+	msg = jsonFragmentPattern.ReplaceAllStringFunc(msg, func(pair string) string {
+		groups := jsonFragmentPattern.FindStringSubmatch(pair)
+		mask, sensitive := p.matchDotted(groups[1])
+		if !sensitive {
+			return pair
+		}
+		value := groups[3]
+		if unquoted, err := unquoteJSON(value); err == nil {
+			value = unquoted
+		}
+		masked, _ := json.Marshal(maskMessageValue(mask, value))
+		return `"` + groups[1] + `"` + groups[2] + string(masked)
+	})
+	return keyValuePattern.ReplaceAllStringFunc(msg, func(pair string) string {
+		groups := keyValuePattern.FindStringSubmatch(pair)
+		mask, sensitive := p.matchDotted(groups[2])
+		if !sensitive {
+			return pair
+		}
+		value, quote := groups[4], ""
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+			quote, value = value[:1], value[1:len(value)-1]
+		}
+		return groups[1] + groups[2] + groups[3] + quote + maskMessageValue(mask, value) + quote
+	})
+}
+
+// matchDotted matches a key found in a message, reading a dotted key such as "customer.name" as a path.
+func (p *maskingPolicy) matchDotted(key string) (maskFunc, bool) {
+	segments := strings.Split(key, ".")
+	return p.match(segments[:len(segments)-1], segments[len(segments)-1])
+}
+
+// maskMessageValue masks a value of a message, redacting it when the strategy drops it.
+func maskMessageValue(mask maskFunc, value string) string {
+	if masked, keep := mask(value); keep {
+		return masked
+	}
+	return redactedValue
+}
+
+// unquoteJSON decodes a JSON string literal.
+func unquoteJSON(value string) (string, error) {
+	var unquoted string
+	err := json.Unmarshal([]byte(value), &unquoted)
+	return unquoted, err
+}
+
+// maskArgs replaces the Msgf arguments that are structs, maps, slices or pointers to them by their masked plain
+// value, so that the sensitive fields they hold never reach the formatted message. Values formatting themselves
+// are left to the message masking.
+func (p *maskingPolicy) maskArgs(args []interface{}) []interface{} {
+	if !p.enabled {
+		return args
+	}
+	var masked []interface{}
+	for i, arg := range args {
+		switch arg.(type) {
+		case nil, fmt.Stringer, fmt.Formatter, error:
+			continue
+		}
+		v := indirect(reflect.ValueOf(arg))
+		if !v.IsValid() {
+			continue
+		}
+		switch v.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			if masked == nil {
+				masked = make([]interface{}, len(args))
+				copy(masked, args)
+			}
+			masked[i] = p.plainValue(nil, v, nil)
+		}
+	}
+	if masked == nil {
+		return args
+	}
+	return masked
+}
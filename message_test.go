@@ -0,0 +1,105 @@
+package zlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// TestMaskMessage verifies the masking of key=value pairs, JSON fragments and detected values in a message.
+func TestMaskMessage(t *testing.T) {
+	logger := New(&Config{
+		Level: "debug",
+		Masking: MaskingConfig{
+			Enabled:         true,
+			SensitiveFields: []string{"customer.name"},
+			Detectors:       []string{DetectorEmail},
+			Strategies:      map[string]MaskStrategy{"cardno": KeepLast(4)},
+		},
+	})
+
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"KeyValue", "login jay password=P@ss", "login jay password=***"},
+		{"KeyColonValue", "Password: P@ss, user: jay", "Password: ***, user: jay"},
+		{"QuotedValue", `password="P@ss word" ok`, `password="***" ok`},
+		{"BearerValue", "authorization=Bearer abc.def done", "authorization=*** done"},
+		{"Strategy", "cardno=4111111111111111", "cardno=************1111"},
+		{"DottedKeyPath", "customer.name=John product.title=Gold", "customer.name=*** product.title=Gold"},
+		{"JSONFragment", `payload {"password":"P@ss","id":1,"cvc":123}`, `payload {"password":"***","id":1,"cvc":"***"}`},
+		{"JSONEscapedValue", `{"password": "a\"b"}`, `{"password": "***"}`},
+		{"Detector", "sent to john@doe.com", "sent to ***"},
+		{"NoSensitiveValue", "user=jay status=ok", "user=jay status=ok"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := logger.maskMessage(tc.input); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestEventMessages verifies that Msg, Msgf and MsgFunc emit masked messages, including structured Msgf arguments.
+func TestEventMessages(t *testing.T) {
+	type credentials struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+	}
+	cases := []struct {
+		name    string
+		masking bool
+		send    func(e *Event)
+		want    string
+	}{
+		{"Msg", true, func(e *Event) { e.Msg("password=P@ss") }, "password=***"},
+		{"Msgf", true, func(e *Event) { e.Msgf("login %s password=%s", "jay", "P@ss") }, "login jay password=***"},
+		{"MsgfStructArg", true, func(e *Event) { e.Msgf("login %v", credentials{User: "jay", Password: "P@ss"}) }, "login map[password:*** user:jay]"},
+		{"MsgFunc", true, func(e *Event) { e.MsgFunc(func() string { return "pin=1234 password=P@ss" }) }, "pin=1234 password=***"},
+		{"MaskingDisabled", false, func(e *Event) { e.Msgf("password=%s", "P@ss") }, "password=P@ss"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&Config{Level: "debug", Masking: MaskingConfig{Enabled: tc.masking}})
+			zl := zerolog.New(&buf)
+			tc.send(logger.Event(zl.Info()))
+
+			entry := make(map[string]interface{})
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid log line %q: %v", buf.String(), err)
+			}
+			if got := entry[zerolog.MessageFieldName]; got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// countingStringer counts the calls to String.
+type countingStringer struct{ calls *int }
+
+func (s countingStringer) String() string {
+	*s.calls++
+	return "value"
+}
+
+// TestDisabledEventMessages verifies that the message of a disabled event is neither built nor formatted.
+func TestDisabledEventMessages(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{Level: "info", Masking: MaskingConfig{Enabled: true}, Outputs: []OutputConfig{{Writer: &buf}}})
+	calls := 0
+	logger.Event(logger.Debug()).Msgf("password=%s", countingStringer{&calls})
+	logger.Event(logger.Debug()).MsgFunc(func() string {
+		calls++
+		return "hidden"
+	})
+	if calls != 0 || buf.Len() != 0 {
+		t.Errorf("expected nothing built or written, got %d calls and %q", calls, buf.String())
+	}
+}
@@ -0,0 +1,255 @@
+package zlogs
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Headers read and echoed by the HTTP middleware.
+const (
+	HeaderRequestID     = "X-Request-ID"
+	HeaderCorrelationID = "X-Correlation-ID"
+	HeaderTraceparent   = "traceparent"
+)
+
+// headersKey is the key of the request headers in the access log.
+const headersKey = "headers"
+
+// credentialHeaders are the lowercase names of the headers carrying credentials, always redacted in the access log.
+var credentialHeaders = map[string]struct{}{
+	"authorization": {}, "proxy-authorization": {}, "cookie": {}, "set-cookie": {}, "x-api-key": {},
+}
+
+// HTTPConfig configures the HTTP middleware of a Logger.
+type HTTPConfig struct {
+	// OmitHeaders leaves the request headers out of the access log.
+	OmitHeaders bool
+	// SkipPaths lists the URL paths without access log, e.g. health checks. Their IDs are still set.
+	SkipPaths []string
+	// LogRequestBody and LogResponseBody add the bodies to the access log, masked by the masking policy of the
+	// Logger. JSON and form bodies are logged as fields, other text bodies as masked text, and binary or encoded
+	// bodies are left out.
+	LogRequestBody  bool
+	LogResponseBody bool
+	// MaxBodySize is the number of bytes captured from each body, 16 KiB by default. A longer text body is logged
+	// truncated, as masked text. A longer JSON or form body is left out, as its fields cannot be masked reliably,
+	// and only its size is logged. Both are flagged by request_body_truncated or response_body_truncated.
+	MaxBodySize int
+}
+
+// HTTPMiddleware returns a net/http middleware setting up the logging of each request. It reads the X-Request-ID,
+// traceparent and X-Correlation-ID headers, generating the missing ones, stores them in the request context along
+// with the Logger, so that Ctx(r.Context()) logs them, and echoes them on the response. Once the request is served,
+// it writes one access log with the httpRequest field, and the request headers masked by the masking policy of the
+// Logger, the credential headers such as Authorization and Cookie being always redacted. The entry is at the error
+// level for a server error, at the warn level for a client error and at the info level otherwise. It carries no
+// caller information, which would only point at the middleware.
+func (l *Logger) HTTPMiddleware(config HTTPConfig) func(http.Handler) http.Handler {
+	skip := make(map[string]struct{}, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = struct{}{}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx := l.requestContext(w, r)
+			_, skipped := skip[r.URL.Path]
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			var requestBody *bodyCapture
+			if !skipped && config.LogRequestBody {
+				requestBody = captureRequestBody(r, config.maxBodySize())
+			}
+			if !skipped && config.LogResponseBody {
+				recorder.body = &bodyCapture{limit: config.maxBodySize()}
+			}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+			if skipped {
+				return
+			}
+			l.accessLog(ctx, r, requestBody, recorder, time.Since(start), config)
+		})
+	}
+}
+
+// requestContext returns the context of a request carrying its IDs and the Logger, echoing the IDs on the response.
+func (l *Logger) requestContext(w http.ResponseWriter, r *http.Request) context.Context {
+	requestID := r.Header.Get(HeaderRequestID)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	correlationID := r.Header.Get(HeaderCorrelationID)
+	if correlationID == "" {
+		correlationID = requestID
+	}
+	sc, err := ParseTraceparent(r.Header.Get(HeaderTraceparent))
+	if err != nil {
+		sc = newSpanContext()
+	}
+
+	w.Header().Set(HeaderRequestID, requestID)
+	w.Header().Set(HeaderCorrelationID, correlationID)
+	w.Header().Set(HeaderTraceparent, FormatTraceparent(sc))
+
+	ctx := WithRequestID(r.Context(), requestID)
+	ctx = WithCorrelationID(ctx, correlationID)
+	ctx = WithSpanContext(ctx, sc)
+	return NewContext(ctx, l)
+}
+
+// accessLog writes the access log of a request.
+func (l *Logger) accessLog(ctx context.Context, r *http.Request, requestBody *bodyCapture, recorder *responseRecorder,
+	latency time.Duration, config HTTPConfig) {
+	level := zerolog.InfoLevel
+	switch {
+	case recorder.status >= http.StatusInternalServerError:
+		level = zerolog.ErrorLevel
+	case recorder.status >= http.StatusBadRequest:
+		level = zerolog.WarnLevel
+	}
+	event := l.Event(l.WithLevel(level).Ctx(context.WithValue(ctx, noCallerKey, true))).WithHTTPRequest(HTTPRequest{
+		Method:       r.Method,
+		URL:          r.URL.Path,
+		Status:       recorder.status,
+		RequestSize:  max(r.ContentLength, 0),
+		ResponseSize: recorder.bytes,
+		UserAgent:    r.UserAgent(),
+		RemoteIP:     remoteIP(r.RemoteAddr),
+		Referer:      r.Referer(),
+		Protocol:     r.Proto,
+		Latency:      latency,
+	})
+	if !config.OmitHeaders && len(r.Header) > 0 {
+		event = event.WithFields(map[string]interface{}{headersKey: headerFields(r.Header)})
+	}
+	event.withBody(requestBodyKey, r.Header, requestBody, r.ContentLength).
+		withBody(responseBodyKey, recorder.Header(), recorder.body, recorder.bytes).
+		Msg("http request")
+}
+
+// headerFields returns the headers keyed by their lowercase names, with their values joined by commas and the
+// credential headers redacted.
+func headerFields(header http.Header) map[string]interface{} {
+	fields := make(map[string]interface{}, len(header))
+	for name, values := range header {
+		name = strings.ToLower(name)
+		if _, ok := credentialHeaders[name]; ok {
+			fields[name] = redactedValue
+			continue
+		}
+		fields[name] = strings.Join(values, ", ")
+	}
+	return fields
+}
+
+// remoteIP returns the IP of a remote address such as "10.0.0.1:52000".
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// newRequestID returns a random version 4 UUID.
+func newRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	s := hex.EncodeToString(id[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// newSpanContext returns a SpanContext with random IDs, not sampled, for a request without a valid traceparent.
+func newSpanContext() SpanContext {
+	var traceID [16]byte
+	var spanID [8]byte
+	_, _ = rand.Read(traceID[:])
+	_, _ = rand.Read(spanID[:])
+	return traceparent{traceID: hex.EncodeToString(traceID[:]), spanID: hex.EncodeToString(spanID[:])}
+}
+
+// responseRecorder is an http.ResponseWriter recording the status and the size of a response, and capturing the
+// beginning of its body when body is set.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+	body        *bodyCapture
+}
+
+// WriteHeader records the final status and writes it.
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader && status >= http.StatusOK {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the size of the body, captures its beginning when asked, and writes it.
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	if r.body != nil {
+		r.body.write(p[:n])
+	}
+	return n, err
+}
+
+// Flush flushes the response when the underlying writer supports it.
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// ReadFrom writes the content of src as the body, letting the underlying writer copy it directly, e.g. with
+// sendfile, unless the body is captured.
+func (r *responseRecorder) ReadFrom(src io.Reader) (int64, error) {
+	readerFrom, ok := r.ResponseWriter.(io.ReaderFrom)
+	if !ok || r.body != nil {
+		return io.Copy(struct{ io.Writer }{r}, src)
+	}
+	r.wroteHeader = true
+	n, err := readerFrom.ReadFrom(src)
+	r.bytes += n
+	return n, err
+}
+
+// Hijack lets the handler take over the connection, e.g. for a WebSocket upgrade, recording the 101 status.
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && !r.wroteHeader {
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Push initiates an HTTP/2 server push when the underlying writer supports it.
+func (r *responseRecorder) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := r.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
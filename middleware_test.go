@@ -0,0 +1,215 @@
+package zlogs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveMiddleware serves a request through the middleware of a Logger writing to buf, and returns the response.
+func serveMiddleware(t *testing.T, buf *bytes.Buffer, config HTTPConfig, handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	logger := New(&Config{Masking: MaskingConfig{Enabled: true}, Outputs: []OutputConfig{{Writer: buf}}})
+	rec := httptest.NewRecorder()
+	logger.HTTPMiddleware(config)(handler).ServeHTTP(rec, r)
+	return rec
+}
+
+// decodeEntries decodes the JSON lines of buf.
+func decodeEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid entry %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestHTTPMiddleware verifies the IDs read from the request and the access log.
+func TestHTTPMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	r := httptest.NewRequest(http.MethodPost, "/orders?token=secret", strings.NewReader("{}"))
+	r.RemoteAddr = "10.0.0.1:52000"
+	r.Header.Set("User-Agent", "test")
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("X-Api-Key", "secret")
+	r.Header.Set("Cookie", "session=secret")
+	r.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	r.Header.Set(HeaderRequestID, "req-1")
+	r.Header.Set(HeaderCorrelationID, "corr-1")
+	r.Header.Set(HeaderTraceparent, testTraceparent)
+
+	rec := serveMiddleware(t, &buf, HTTPConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		l := Ctx(r.Context())
+		l.Event(l.Info()).Msg("in the handler")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}, r)
+
+	if rec.Header().Get(HeaderRequestID) != "req-1" || rec.Header().Get(HeaderCorrelationID) != "corr-1" ||
+		rec.Header().Get(HeaderTraceparent) != testTraceparent {
+		t.Errorf("expected the IDs to be echoed, got %v", rec.Header())
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("expected the secrets to be masked, got %s", buf.String())
+	}
+	entries := decodeEntries(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected the handler entry and the access log, got %d entries", len(entries))
+	}
+	for _, entry := range entries {
+		if entry["request_id"] != "req-1" || entry["correlation_id"] != "corr-1" ||
+			entry["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || entry["span_id"] != "00f067aa0ba902b7" {
+			t.Errorf("expected the IDs in the entry, got %v", entry)
+		}
+	}
+	access := entries[1]
+	request, _ := access[httpRequestKey].(map[string]interface{})
+	if access["severity"] != "info" || request["requestMethod"] != "POST" || request["requestUrl"] != "/orders" ||
+		request["status"] != float64(http.StatusCreated) || request["responseSize"] != "7" || request["requestSize"] != "2" ||
+		request["remoteIp"] != "10.0.0.1" || request["userAgent"] != "test" || request["latency"] == nil {
+		t.Errorf("unexpected access log %v", access)
+	}
+	if entries[0][fileKey] == nil || access[fileKey] != nil || access[funcKey] != nil {
+		t.Errorf("expected the caller on the handler entry only, got %v and %v", entries[0], access)
+	}
+	headers, _ := access[headersKey].(map[string]interface{})
+	if headers["authorization"] != redactedValue || headers["x-api-key"] != redactedValue || headers["user-agent"] != "test" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+}
+
+// TestHeaderFieldsCredentials verifies that the credential headers are redacted even without masking.
+func TestHeaderFieldsCredentials(t *testing.T) {
+	header := http.Header{}
+	header.Set("Cookie", "session=secret")
+	header.Set("Set-Cookie", "session=secret")
+	header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	header.Set("Accept", "text/html")
+	want := map[string]interface{}{
+		"cookie": redactedValue, "set-cookie": redactedValue, "proxy-authorization": redactedValue, "accept": "text/html",
+	}
+	if got := headerFields(header); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestHTTPMiddlewareHijack verifies that a handler can take over the connection for a WebSocket upgrade.
+func TestHTTPMiddlewareHijack(t *testing.T) {
+	var buf syncBuffer
+	logger := New(&Config{Outputs: []OutputConfig{{Writer: &buf}}})
+	server := httptest.NewServer(logger.HTTPMiddleware(HTTPConfig{OmitHeaders: true})(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, rw, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("cannot hijack: %v", err)
+				return
+			}
+			defer conn.Close()
+			_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+			_ = rw.Flush()
+		})))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("expected the upgrade, got %d", resp.StatusCode)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), `"status":101`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the access log with the 101 status, got %q", buf.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestHTTPMiddlewareGeneratedIDs verifies the IDs generated for a request without them.
+func TestHTTPMiddlewareGeneratedIDs(t *testing.T) {
+	var buf bytes.Buffer
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HeaderTraceparent, "invalid")
+	rec := serveMiddleware(t, &buf, HTTPConfig{OmitHeaders: true}, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}, r)
+
+	requestID := rec.Header().Get(HeaderRequestID)
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuid.MatchString(requestID) || rec.Header().Get(HeaderCorrelationID) != requestID {
+		t.Errorf("unexpected IDs %v", rec.Header())
+	}
+	sc, err := ParseTraceparent(rec.Header().Get(HeaderTraceparent))
+	if err != nil || sc.TraceFlags() != 0 {
+		t.Errorf("expected a new unsampled traceparent, got %q: %v", rec.Header().Get(HeaderTraceparent), err)
+	}
+	access := decodeEntries(t, &buf)[0]
+	if access["severity"] != "error" || access["request_id"] != requestID || access[headersKey] != nil {
+		t.Errorf("unexpected access log %v", access)
+	}
+}
+
+// TestHTTPMiddlewareSkipPaths verifies that the skipped paths have no access log.
+func TestHTTPMiddlewareSkipPaths(t *testing.T) {
+	var buf bytes.Buffer
+	config := HTTPConfig{SkipPaths: []string{"/healthz"}}
+	rec := serveMiddleware(t, &buf, config, func(w http.ResponseWriter, r *http.Request) {}, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if buf.Len() != 0 || rec.Header().Get(HeaderRequestID) == "" {
+		t.Errorf("expected the IDs without access log, got %q", buf.String())
+	}
+}
+
+// TestResponseRecorderReadFrom verifies that a body copied by ReadFrom is recorded and captured.
+func TestResponseRecorderReadFrom(t *testing.T) {
+	recorder := &responseRecorder{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK,
+		body: &bodyCapture{limit: defaultMaxBodySize}}
+	if n, err := io.Copy(recorder, strings.NewReader("hello")); err != nil || n != 5 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	if recorder.bytes != 5 || recorder.body.data.String() != "hello" {
+		t.Errorf("expected the body recorded, got %d bytes and %q", recorder.bytes, recorder.body.data.String())
+	}
+}
+
+// TestResponseRecorder verifies the recorded status of a response.
+func TestResponseRecorder(t *testing.T) {
+	cases := []struct {
+		name  string
+		write func(w http.ResponseWriter)
+		want  int
+	}{
+		{"Implicit", func(w http.ResponseWriter) { _, _ = w.Write([]byte("ok")) }, http.StatusOK},
+		{"Informational", func(w http.ResponseWriter) { w.WriteHeader(http.StatusEarlyHints); w.WriteHeader(http.StatusNotFound) }, http.StatusNotFound},
+		{"First", func(w http.ResponseWriter) { w.WriteHeader(http.StatusAccepted); w.WriteHeader(http.StatusNotFound) }, http.StatusAccepted},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := &responseRecorder{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+			tc.write(recorder)
+			if recorder.status != tc.want {
+				t.Errorf("got %d, want %d", recorder.status, tc.want)
+			}
+		})
+	}
+}
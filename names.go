@@ -0,0 +1,102 @@
+package zlogs
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+// withDefaults returns the field names, replacing the empty ones with the default names.
+func (n FieldNames) withDefaults() FieldNames {
+	if n.Timestamp == "" {
+		n.Timestamp = defaultFieldNames.Timestamp
+	}
+	if n.Level == "" {
+		n.Level = defaultFieldNames.Level
+	}
+	if n.Message == "" {
+		n.Message = defaultFieldNames.Message
+	}
+	return n
+}
+
+// fieldNamesWriter is an io.Writer renaming the level and message fields written by zerolog under its global
+// field names to the field names of a Logger, so that the zerolog globals never need to be changed.
+type fieldNamesWriter struct {
+	out   io.Writer
+	names FieldNames
+}
+
+// newFieldNamesWriter wraps out with a fieldNamesWriter renaming the fields to names.
+func newFieldNamesWriter(out io.Writer, names FieldNames) *fieldNamesWriter {
+	return &fieldNamesWriter{out: out, names: names}
+}
+
+// Write renames the fields of every line of p and writes them to the underlying writer.
+func (w *fieldNamesWriter) Write(p []byte) (int, error) {
+	if _, err := w.out.Write(mapLines(p, w.renameLine)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteLevel renames the fields of every line of p and writes them to the underlying writer, keeping the level for
+// a zerolog.LevelWriter.
+func (w *fieldNamesWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	lw, ok := w.out.(zerolog.LevelWriter)
+	if !ok {
+		return w.Write(p)
+	}
+	if _, err := lw.WriteLevel(level, mapLines(p, w.renameLine)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// renameLine renames the level, which zerolog writes first, and the message, which zerolog writes last. The other
+// fields are left untouched, without decoding the line.
+func (w *fieldNamesWriter) renameLine(line []byte) []byte {
+	if from := zerolog.LevelFieldName; from != "" && from != w.names.Level {
+		prefix := []byte(`{"` + from + `":`)
+		if bytes.HasPrefix(line, prefix) {
+			line = append([]byte(`{"`+w.names.Level+`":`), line[len(prefix):]...)
+		}
+	}
+	if from := zerolog.MessageFieldName; from != "" && from != w.names.Message {
+		line = renameLastField(line, from, w.names.Message)
+	}
+	return line
+}
+
+// renameLastField renames the key of the last top-level field of a JSON object when it is a string named from.
+// A nested field of the same name, which is followed by the end of its own object, is left untouched.
+func renameLastField(line []byte, from, to string) []byte {
+	key := []byte(`"` + from + `":"`)
+	at := bytes.LastIndex(line, key)
+	if at < 1 || (line[at-1] != ',' && line[at-1] != '{') {
+		return line
+	}
+	value := line[at+len(key)-1:]
+	end := endOfJSONString(value)
+	if end < 0 || !bytes.Equal(bytes.TrimSpace(value[end:]), []byte{'}'}) {
+		return line
+	}
+	renamed := make([]byte, 0, len(line)+len(to)-len(from))
+	renamed = append(renamed, line[:at]...)
+	renamed = append(renamed, `"`+to+`":`...)
+	return append(renamed, value...)
+}
+
+// endOfJSONString returns the index following the closing quote of the JSON string starting s, or -1.
+func endOfJSONString(s []byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,126 @@
+package zlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	zlog "github.com/rs/zerolog/log"
+)
+
+// TestNewKeepsZerologGlobals verifies that creating a Logger leaves the zerolog globals and zlog.Logger untouched.
+func TestNewKeepsZerologGlobals(t *testing.T) {
+	timestamp, level, message := zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName
+	globalLevel, global, standard := zerolog.GlobalLevel(), zlog.Logger, std
+	defer func() { std = standard }()
+
+	New(&Config{Level: "error", FieldNames: FieldNames{Message: "msg"}})
+	NewLogger(&Config{Level: "warn"})
+
+	if zerolog.TimestampFieldName != timestamp || zerolog.LevelFieldName != level || zerolog.MessageFieldName != message {
+		t.Error("expected the zerolog field names to be untouched")
+	}
+	if zerolog.GlobalLevel() != globalLevel {
+		t.Errorf("expected the zerolog global level to be untouched, got %v", zerolog.GlobalLevel())
+	}
+	if !reflect.DeepEqual(zlog.Logger, global) {
+		t.Error("expected zlog.Logger to be untouched")
+	}
+}
+
+// TestFieldNames verifies that each Logger writes its own field names and level.
+func TestFieldNames(t *testing.T) {
+	var custom, standard bytes.Buffer
+	customLogger := New(&Config{
+		Level:      "info",
+		FieldNames: FieldNames{Timestamp: "ts", Level: "lvl", Message: "msg"},
+		Outputs:    []OutputConfig{{Writer: &custom}},
+	})
+	standardLogger := New(&Config{Level: "debug", Outputs: []OutputConfig{{Writer: &standard}}})
+
+	customLogger.Event(customLogger.Debug()).Msg("filtered")
+	customLogger.Event(customLogger.Info()).WithField("nested", map[string]interface{}{"message": "kept"}).Msg("custom")
+	standardLogger.Event(standardLogger.Debug()).Msg("standard")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(custom.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single entry, got %q: %v", custom.String(), err)
+	}
+	if entry["lvl"] != "info" || entry["msg"] != "custom" || entry["ts"] == nil {
+		t.Errorf("unexpected custom entry %v", entry)
+	}
+	if nested := entry["nested"].(map[string]interface{}); nested["message"] != "kept" {
+		t.Errorf("expected the nested message untouched, got %v", nested)
+	}
+	entry = nil
+	if err := json.Unmarshal(standard.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["severity"] != "debug" || entry["message"] != "standard" || entry["timestamp"] == nil {
+		t.Errorf("unexpected standard entry %v", entry)
+	}
+}
+
+// TestRenameLine verifies the renaming of the level and message fields written by zerolog.
+func TestRenameLine(t *testing.T) {
+	w := newFieldNamesWriter(nil, FieldNames{Timestamp: "ts", Level: "lvl", Message: "msg"})
+	cases := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"LevelAndMessage", `{"level":"info","a":1,"message":"hello"}`, `{"lvl":"info","a":1,"msg":"hello"}`},
+		{"EscapedMessage", `{"level":"info","message":"say \"message\":\"x\""}`, `{"lvl":"info","msg":"say \"message\":\"x\""}`},
+		{"NestedMessageOnly", `{"level":"info","data":{"message":"x"}}`, `{"lvl":"info","data":{"message":"x"}}`},
+		{"NoLevel", `{"message":"hello"}`, `{"msg":"hello"}`},
+		{"NotJSON", `plain "message":"x"`, `plain "message":"x"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(w.renameLine([]byte(tc.line))); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestSetAsGlobal verifies that SetAsGlobal applies the field names of the Logger to zerolog and leaves the level to
+// the Logger, so that SetLevel still applies.
+func TestSetAsGlobal(t *testing.T) {
+	timestamp, level, message := zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName
+	globalLevel, global, standard := zerolog.GlobalLevel(), zlog.Logger, std
+	defer func() {
+		zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName = timestamp, level, message
+		zerolog.SetGlobalLevel(globalLevel)
+		zlog.Logger, std = global, standard
+	}()
+
+	var buf bytes.Buffer
+	logger := New(&Config{Level: "warn", Outputs: []OutputConfig{{Writer: &buf}}})
+	logger.SetAsGlobal()
+
+	if zerolog.LevelFieldName != "severity" || zerolog.GlobalLevel() != zerolog.TraceLevel || GetLogger() != logger {
+		t.Error("expected the Logger to be global")
+	}
+	zlog.Info().Msg("hidden")
+	zlog.Warn().Msg("through zerolog")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["severity"] != "warn" || entry["message"] != "through zerolog" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+
+	buf.Reset()
+	if err := logger.SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	Debug().Msg("after SetLevel")
+	if !strings.Contains(buf.String(), "after SetLevel") {
+		t.Errorf("expected the debug entry once the level is lowered, got %q", buf.String())
+	}
+}
@@ -0,0 +1,141 @@
+package zlogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// OutputStdout and OutputStderr are the OutputConfig paths selecting the standard output streams.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// output is the writer of a logger, along with the asynchronous writer and the files to release on Close.
+type output struct {
+	writer  io.Writer
+	async   *AsyncWriter
+	masking *MaskingWriter
+	closers []io.Closer
+}
+
+// newOutput returns the writer of a logger, renaming the fields of the entries and adapting them to the profile of
+// the config, masking them when the config asks for masking at the output and writing them asynchronously when the
+// config enables it.
+func newOutput(config *Config) *output {
+	names := config.FieldNames.withDefaults()
+	out := newOutputs(config.Outputs, config.Format, profileFieldNames(config.Profile, names))
+	out.writer = newFieldNamesWriter(newProfileWriter(out.writer, config.Profile, config.ProjectID, names), names)
+	if config.Masking.Enabled && config.Masking.AtOutput {
+		out.masking = NewMaskingWriter(out.writer, config.Masking)
+		out.writer = out.masking
+	}
+	if config.Async.Enabled {
+		out.async = NewAsyncWriter(out.writer, config.Async)
+		out.writer = out.async
+	}
+	return out
+}
+
+// newOutputs combines the configured outputs into a single writer in the given format, defaulting to os.Stdout.
+// The names are the field names of the entries written to the outputs.
+func newOutputs(outputs []OutputConfig, format Format, names FieldNames) *output {
+	if len(outputs) == 0 {
+		return &output{writer: formatWriter(format, os.Stdout, names)}
+	}
+	out := &output{}
+	writers := make([]io.Writer, 0, len(outputs))
+	for _, config := range outputs {
+		w := config.writer()
+		if config.Writer == nil {
+			if closer, ok := w.(io.Closer); ok && w != os.Stdout && w != os.Stderr {
+				out.closers = append(out.closers, closer)
+			}
+		}
+		writers = append(writers, config.filtered(formatWriter(format, w, names)))
+	}
+	if len(writers) == 1 {
+		out.writer = writers[0]
+	} else {
+		out.writer = zerolog.MultiLevelWriter(writers...)
+	}
+	return out
+}
+
+// flush waits for the entries buffered by the asynchronous writer, if any.
+func (o *output) flush(ctx context.Context) error {
+	if o == nil || o.async == nil {
+		return nil
+	}
+	return o.async.Flush(ctx)
+}
+
+// close writes the buffered entries and closes the files opened for the outputs.
+func (o *output) close() error {
+	if o == nil {
+		return nil
+	}
+	var errs []error
+	if o.async != nil {
+		errs = append(errs, o.async.Close())
+	}
+	for _, closer := range o.closers {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// writer returns the writer of the output, opening its path when no Writer is set. It panics when the file of the
+// output cannot be opened.
+func (o OutputConfig) writer() io.Writer {
+	if o.Writer != nil {
+		return o.Writer
+	}
+	return openOutput(o.Path, o.Rotation)
+}
+
+// filtered filters the writer of the output by its minimum level. An invalid level writes every entry, like an
+// invalid Config.Level.
+func (o OutputConfig) filtered(w io.Writer) io.Writer {
+	if o.Level == "" {
+		return w
+	}
+	level, err := zerolog.ParseLevel(o.Level)
+	if err != nil {
+		return w
+	}
+	lw, ok := w.(zerolog.LevelWriter)
+	if !ok {
+		lw = zerolog.LevelWriterAdapter{Writer: w}
+	}
+	return &zerolog.FilteredLevelWriter{Writer: lw, Level: level}
+}
+
+// openOutput returns the standard stream named by path, or opens the file at path for appending, rotating it when
+// the rotation is configured.
+func openOutput(path string, rotation RotationConfig) io.Writer {
+	switch strings.ToLower(path) {
+	case "", OutputStdout:
+		return os.Stdout
+	case OutputStderr:
+		return os.Stderr
+	}
+	if rotation.enabled() {
+		file, err := NewRotatingFile(path, rotation)
+		if err != nil {
+			panic(fmt.Sprintf("zlogs: cannot open output %q: %v", path, err))
+		}
+		return file
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		panic(fmt.Sprintf("zlogs: cannot open output %q: %v", path, err))
+	}
+	return file
+}
@@ -0,0 +1,74 @@
+package zlogs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOutputs verifies that the entries are written to every output allowed by its minimum level.
+func TestOutputs(t *testing.T) {
+	var all, errs bytes.Buffer
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger := New(&Config{
+		Level: "debug",
+		Outputs: []OutputConfig{
+			{Writer: &all},
+			{Writer: &errs, Level: "error"},
+			{Path: path, Level: "info"},
+		},
+	})
+
+	logger.Event(logger.Debug()).Msg("debug entry")
+	logger.Event(logger.Error()).Msg("error entry")
+
+	if got := all.String(); !strings.Contains(got, "debug entry") || !strings.Contains(got, "error entry") {
+		t.Errorf("expected every entry, got %q", got)
+	}
+	if got := errs.String(); strings.Contains(got, "debug entry") || !strings.Contains(got, "error entry") {
+		t.Errorf("expected the error entry only, got %q", got)
+	}
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(file); strings.Contains(got, "debug entry") || !strings.Contains(got, "error entry") {
+		t.Errorf("expected the error entry only, got %q", got)
+	}
+}
+
+// TestOutputsMaskedAtOutput verifies that masking at the output applies to every configured output.
+func TestOutputsMaskedAtOutput(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{
+		Level:   "debug",
+		Masking: MaskingConfig{Enabled: true, AtOutput: true},
+		Outputs: []OutputConfig{{Writer: &buf}},
+	})
+	logger.Info().Str("password", "P@ss").Msg("raw zerolog entry")
+
+	if got := buf.String(); !strings.Contains(got, `"password":"***"`) {
+		t.Errorf("expected masked password, got %q", got)
+	}
+}
+
+// TestOpenOutput verifies the selection of the standard streams by path.
+func TestOpenOutput(t *testing.T) {
+	cases := []struct {
+		path string
+		want *os.File
+	}{
+		{"", os.Stdout},
+		{OutputStdout, os.Stdout},
+		{"STDERR", os.Stderr},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			if got := openOutput(tc.path, RotationConfig{}); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package zlogs
+
+import (
+	"strconv"
+	"strings"
+)
+
+// fieldPath is the location of a field from the root of the log entry, one segment per key and "[i]" per array index.
+type fieldPath []string
+
+// pathSelector matches field paths such as "customer.name", "items[*].card.number" or "**.password".
+// "*" matches a single key, "[*]" any array index and "**" any number of segments.
+type pathSelector struct {
+	rule     string
+	segments []string
+	mask     maskFunc
+}
+
+// isPathSelector reports whether a sensitive field entry is a path selector rather than a plain key name.
+func isPathSelector(field string) bool {
+	return strings.HasPrefix(field, "!") || strings.ContainsAny(field, ".[*")
+}
+
+// parsePathSelector splits a selector into its segments, separating the array indexes from the keys.
+func parsePathSelector(selector string) pathSelector {
+	var segments []string
+	for _, part := range strings.Split(strings.ToLower(selector), ".") {
+		for part != "" {
+			open := strings.Index(part, "[")
+			if open < 0 {
+				segments = append(segments, part)
+				break
+			}
+			if open > 0 {
+				segments = append(segments, part[:open])
+			}
+			end := strings.Index(part[open:], "]")
+			if end < 0 {
+				segments = append(segments, part[open:])
+				break
+			}
+			segments = append(segments, part[open:open+end+1])
+			part = part[open+end+1:]
+		}
+	}
+	return pathSelector{rule: selector, segments: segments}
+}
+
+// matches reports whether the selector matches the full path of a field.
+func (s pathSelector) matches(path fieldPath) bool {
+	return matchSegments(s.segments, path)
+}
+
+// matchSegments matches the selector segments against the path segments, expanding "**" to any number of segments.
+func matchSegments(pattern []string, path fieldPath) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 || !matchSegment(pattern[0], path[0]) {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+// matchSegment matches a single selector segment against a path segment.
+func matchSegment(pattern, segment string) bool {
+	isIndex := strings.HasPrefix(segment, "[")
+	switch pattern {
+	case "[*]":
+		return isIndex
+	case "*":
+		return !isIndex
+	default:
+		return strings.EqualFold(pattern, segment)
+	}
+}
+
+// child returns the path of a nested key. It returns nil when the policy does not track paths.
+func (p *maskingPolicy) child(path fieldPath, key string) fieldPath {
+	if !p.tracksPaths() {
+		return nil
+	}
+	return append(path[:len(path):len(path)], key)
+}
+
+// childIndex returns the path of an array element. It returns nil when the policy does not track paths.
+func (p *maskingPolicy) childIndex(path fieldPath, index int) fieldPath {
+	if !p.tracksPaths() {
+		return nil
+	}
+	return append(path[:len(path):len(path)], "["+strconv.Itoa(index)+"]")
+}
+
+// tracksPaths reports whether the policy needs the full path of the fields to decide on masking or to record them.
+func (p *maskingPolicy) tracksPaths() bool {
+	return len(p.selectors) > 0 || len(p.exclusions) > 0 || p.dryRun
+}
+
+// String formats the path as a selector would, e.g. "items[0].card.number".
+func (path fieldPath) String() string {
+	var b strings.Builder
+	for i, segment := range path {
+		if i > 0 && !strings.HasPrefix(segment, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package zlogs
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestPathSelectors verifies the matching of path selectors against field paths.
+func TestPathSelectors(t *testing.T) {
+	cases := []struct {
+		name     string
+		selector string
+		path     fieldPath
+		expected bool
+	}{
+		{"ExactPath", "customer.name", fieldPath{"customer", "name"}, true},
+		{"ExactPathCaseInsensitive", "customer.name", fieldPath{"Customer", "Name"}, true},
+		{"ExactPathOtherParent", "customer.name", fieldPath{"product", "name"}, false},
+		{"ArrayWildcard", "items[*].card.number", fieldPath{"items", "[3]", "card", "number"}, true},
+		{"ArrayWildcardWithoutIndex", "items[*].card.number", fieldPath{"items", "card", "number"}, false},
+		{"ArrayIndex", "items[0].card", fieldPath{"items", "[0]", "card"}, true},
+		{"AnyDepth", "**.password", fieldPath{"a", "[1]", "b", "password"}, true},
+		{"AnyDepthAtRoot", "**.password", fieldPath{"password"}, true},
+		{"SingleKeyWildcard", "customer.*", fieldPath{"customer", "email"}, true},
+		{"SingleKeyWildcardSkipsIndex", "customer.*", fieldPath{"customer", "[0]"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parsePathSelector(tc.selector).matches(tc.path); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestMaskingByPath verifies that maskFields and maskArrayFields evaluate path selectors and exclusions while recursing.
+func TestMaskingByPath(t *testing.T) {
+	logger := New(&Config{
+		Level: "debug",
+		Masking: MaskingConfig{
+			Enabled: true,
+			SensitiveFields: []string{
+				"items[*].card.number",
+				"**.pin",
+				"!product.name",
+				"!bank.name",
+			},
+			Strategies: map[string]MaskStrategy{"customer.email": MaskEmail},
+		},
+	})
+
+	got := logger.maskFields(nil, map[string]interface{}{
+		"customer": map[string]interface{}{"name": "John", "email": "john@doe.com"},
+		"product":  map[string]interface{}{"name": "Gold card"},
+		"bank":     map[string]interface{}{"name": "KBank", "pin": "1234"},
+		"items": []interface{}{
+			map[string]interface{}{"card": map[string]interface{}{"number": "4111", "type": "visa"}},
+		},
+		"email": "kept@doe.com",
+	})
+	want := map[string]interface{}{
+		"customer": map[string]interface{}{"name": redactedValue, "email": "***@doe.com"},
+		"product":  map[string]interface{}{"name": "Gold card"},
+		"bank":     map[string]interface{}{"name": "KBank", "pin": redactedValue},
+		"items": []interface{}{
+			map[string]interface{}{"card": map[string]interface{}{"number": redactedValue, "type": "visa"}},
+		},
+		"email": "kept@doe.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestStructEncoderByPath verifies that the struct encoder evaluates path selectors.
+func TestStructEncoderByPath(t *testing.T) {
+	type product struct {
+		Name string `json:"name"`
+	}
+	type order struct {
+		Product  product   `json:"product"`
+		Products []product `json:"products"`
+	}
+	logger := New(&Config{
+		Level:   "debug",
+		Masking: MaskingConfig{Enabled: true, SensitiveFields: []string{"!order.product.name"}},
+	})
+
+	got := logWithField(t, logger, "order", order{Product: product{Name: "Gold"}, Products: []product{{Name: "Silver"}}})["order"]
+	want := map[string]interface{}{
+		"product":  map[string]interface{}{"name": "Gold"},
+		"products": []interface{}{map[string]interface{}{"name": redactedValue}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
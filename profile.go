@@ -0,0 +1,324 @@
+package zlogs
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Profile selects the field names and values expected by a log management platform.
+type Profile string
+
+// ProfileDefault keeps the field names of zlogs.
+// ProfileGCP follows the structured logging format of Google Cloud Logging.
+// ProfileECS follows the Elastic Common Schema.
+// ProfileDatadog uses the reserved attributes of Datadog.
+const (
+	ProfileDefault Profile = ""
+	ProfileGCP     Profile = "gcp"
+	ProfileECS     Profile = "ecs"
+	ProfileDatadog Profile = "datadog"
+)
+
+// ecsVersion is the version of the Elastic Common Schema written in ecs.version.
+const ecsVersion = "8.11.0"
+
+// Special fields of Google Cloud Logging.
+const (
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+	gcpTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	httpRequestKey       = "httpRequest"
+)
+
+// gcpSeverities maps the levels to the severities of Google Cloud Logging.
+var gcpSeverities = map[zerolog.Level]string{
+	zerolog.TraceLevel: "DEBUG",
+	zerolog.DebugLevel: "DEBUG",
+	zerolog.InfoLevel:  "INFO",
+	zerolog.WarnLevel:  "WARNING",
+	zerolog.ErrorLevel: "ERROR",
+	zerolog.FatalLevel: "CRITICAL",
+	zerolog.PanicLevel: "ALERT",
+}
+
+// profileWriter is an io.Writer rewriting the top-level fields of each JSON line for a profile.
+type profileWriter struct {
+	out     io.Writer
+	rewrite func(fields []jsonField) []jsonField
+}
+
+// newProfileWriter wraps out with the writer of the profile, if any, for the entries with the given field names.
+// The projectID is the Google Cloud project of ProfileGCP.
+func newProfileWriter(out io.Writer, profile Profile, projectID string, names FieldNames) io.Writer {
+	switch profile {
+	case ProfileGCP:
+		return &profileWriter{out: out, rewrite: gcpFields(projectID, names.Level)}
+	case ProfileECS:
+		return &profileWriter{out: out, rewrite: ecsFields(names.Timestamp, names.Level)}
+	case ProfileDatadog:
+		return &profileWriter{out: out, rewrite: datadogFields(names.Level)}
+	}
+	return out
+}
+
+// profileFieldNames returns the field names of the entries once rewritten by the profile.
+func profileFieldNames(profile Profile, names FieldNames) FieldNames {
+	switch profile {
+	case ProfileECS:
+		names.Timestamp, names.Level = "@timestamp", "log.level"
+	case ProfileDatadog:
+		names.Level = "status"
+	}
+	return names
+}
+
+// Write rewrites every line of p and writes them to the underlying writer.
+func (w *profileWriter) Write(p []byte) (int, error) {
+	if _, err := w.out.Write(mapLines(p, w.rewriteLine)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteLevel rewrites every line of p and writes them to the underlying writer, keeping the level for a
+// zerolog.LevelWriter.
+func (w *profileWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	lw, ok := w.out.(zerolog.LevelWriter)
+	if !ok {
+		return w.Write(p)
+	}
+	if _, err := lw.WriteLevel(level, mapLines(p, w.rewriteLine)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// rewriteLine rewrites the fields of a JSON line, or returns it untouched when it is not a JSON object.
+func (w *profileWriter) rewriteLine(line []byte) []byte {
+	fields, err := decodeFields(line)
+	if err != nil {
+		return line
+	}
+	return encodeFields(w.rewrite(fields))
+}
+
+// encodeFields encodes the fields as a JSON object, in their order.
+func encodeFields(fields []jsonField) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(marshalJSON(field.key))
+		buf.WriteByte(':')
+		buf.Write(field.raw)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// gcpSourceLocation is the sourceLocation special field of Google Cloud Logging.
+type gcpSourceLocation struct {
+	File     string `json:"file,omitempty"`
+	Line     string `json:"line,omitempty"`
+	Function string `json:"function,omitempty"`
+}
+
+// gcpFields returns the rewrite of the fields for Google Cloud Logging: the severities are renamed, the trace
+// becomes the resource name of the trace in the project, the trace flags become trace_sampled, and the caller
+// becomes the sourceLocation. Without a project, a bare trace ID cannot be linked and stays in trace_id.
+func gcpFields(projectID, levelKey string) func(fields []jsonField) []jsonField {
+	return func(fields []jsonField) []jsonField {
+		result := make([]jsonField, 0, len(fields))
+		var source gcpSourceLocation
+		sourceAt := -1
+		for _, field := range fields {
+			switch field.key {
+			case levelKey:
+				field.raw = marshalJSON(gcpSeverity(jsonText(field.raw)))
+			case traceIDKey:
+				trace := jsonText(field.raw)
+				if !strings.HasPrefix(trace, "projects/") {
+					if projectID == "" {
+						break
+					}
+					trace = "projects/" + projectID + "/traces/" + trace
+				}
+				field = jsonField{key: gcpTraceKey, raw: marshalJSON(trace)}
+			case spanIDKey:
+				field.key = gcpSpanIDKey
+			case traceFlagsKey:
+				flags, err := strconv.ParseUint(jsonText(field.raw), 16, 8)
+				field = jsonField{key: gcpTraceSampledKey, raw: marshalJSON(err == nil && flags&1 == 1)}
+			case fileKey, funcKey:
+				if field.key == fileKey {
+					source.File, source.Line = splitFileLine(jsonText(field.raw))
+				} else {
+					source.Function = jsonText(field.raw)
+				}
+				if sourceAt < 0 {
+					sourceAt = len(result)
+					result = append(result, jsonField{key: gcpSourceLocationKey})
+				}
+				continue
+			}
+			result = append(result, field)
+		}
+		if sourceAt >= 0 {
+			result[sourceAt].raw = marshalJSON(source)
+		}
+		return result
+	}
+}
+
+// gcpSeverity returns the severity of Google Cloud Logging for a level name, or DEFAULT when it is unknown.
+func gcpSeverity(level string) string {
+	parsed, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return "DEFAULT"
+	}
+	if severity, ok := gcpSeverities[parsed]; ok {
+		return severity
+	}
+	return "DEFAULT"
+}
+
+// ecsFields returns the rewrite of the fields for the Elastic Common Schema, renaming them to their ECS names and
+// splitting the caller into log.origin.*.
+func ecsFields(timestampKey, levelKey string) func(fields []jsonField) []jsonField {
+	names := map[string]string{
+		timestampKey:                "@timestamp",
+		levelKey:                    "log.level",
+		appNameKey:                  "service.name",
+		traceIDKey:                  "trace.id",
+		spanIDKey:                   "span.id",
+		requestIDKey:                "http.request.id",
+		funcKey:                     "log.origin.function",
+		zerolog.ErrorFieldName:      "error.message",
+		zerolog.ErrorStackFieldName: "error.stack_trace",
+	}
+	return func(fields []jsonField) []jsonField {
+		result := make([]jsonField, 0, len(fields)+2)
+		for _, field := range fields {
+			if field.key == fileKey {
+				file, line := splitFileLine(jsonText(field.raw))
+				result = append(result, jsonField{key: "log.origin.file.name", raw: marshalJSON(file)})
+				if n, err := strconv.Atoi(line); err == nil {
+					result = append(result, jsonField{key: "log.origin.file.line", raw: marshalJSON(n)})
+				}
+				continue
+			}
+			if name, ok := names[field.key]; ok {
+				field.key = name
+			}
+			result = append(result, field)
+		}
+		return append(result, jsonField{key: "ecs.version", raw: marshalJSON(ecsVersion)})
+	}
+}
+
+// datadogFields returns the rewrite of the fields for the reserved and standard attributes of Datadog. The trace
+// and span ids are converted to the decimal ids used by Datadog to correlate the logs with the traces.
+func datadogFields(levelKey string) func(fields []jsonField) []jsonField {
+	names := map[string]string{
+		levelKey:                    "status",
+		appNameKey:                  "service",
+		traceIDKey:                  "dd.trace_id",
+		spanIDKey:                   "dd.span_id",
+		funcKey:                     "logger.method_name",
+		zerolog.ErrorFieldName:      "error.message",
+		zerolog.ErrorStackFieldName: "error.stack",
+	}
+	return func(fields []jsonField) []jsonField {
+		for i, field := range fields {
+			if field.key == traceIDKey || field.key == spanIDKey {
+				fields[i].raw = marshalJSON(datadogID(jsonText(field.raw)))
+			}
+			if name, ok := names[field.key]; ok {
+				fields[i].key = name
+			}
+		}
+		return fields
+	}
+}
+
+// datadogID converts a hex trace or span id, such as a W3C one, to the decimal of its lower 64 bits. Other ids are
+// returned untouched.
+func datadogID(id string) string {
+	if len(id) != 16 && len(id) != 32 {
+		return id
+	}
+	n, err := strconv.ParseUint(id[len(id)-16:], 16, 64)
+	if err != nil {
+		return id
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+// splitFileLine splits a caller such as "main.go:12" into its file and its line.
+func splitFileLine(caller string) (string, string) {
+	colon := strings.LastIndex(caller, ":")
+	if colon < 0 {
+		return caller, ""
+	}
+	return caller[:colon], caller[colon+1:]
+}
+
+// HTTPRequest describes the HTTP request of an entry, written in the httpRequest field with the names of the
+// HttpRequest of Google Cloud Logging.
+type HTTPRequest struct {
+	Method       string
+	URL          string
+	Status       int
+	RequestSize  int64
+	ResponseSize int64
+	UserAgent    string
+	RemoteIP     string
+	ServerIP     string
+	Referer      string
+	Protocol     string
+	Latency      time.Duration
+}
+
+// fields returns the non-zero fields of the request.
+func (r HTTPRequest) fields() map[string]interface{} {
+	fields := make(map[string]interface{})
+	for key, value := range map[string]string{
+		"requestMethod": r.Method,
+		"requestUrl":    r.URL,
+		"userAgent":     r.UserAgent,
+		"remoteIp":      r.RemoteIP,
+		"serverIp":      r.ServerIP,
+		"referer":       r.Referer,
+		"protocol":      r.Protocol,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	if r.Status != 0 {
+		fields["status"] = r.Status
+	}
+	if r.RequestSize != 0 {
+		fields["requestSize"] = strconv.FormatInt(r.RequestSize, 10)
+	}
+	if r.ResponseSize != 0 {
+		fields["responseSize"] = strconv.FormatInt(r.ResponseSize, 10)
+	}
+	if r.Latency != 0 {
+		fields["latency"] = strconv.FormatFloat(r.Latency.Seconds(), 'f', -1, 64) + "s"
+	}
+	return fields
+}
+
+// WithHTTPRequest adds the HTTP request to the log entry in the httpRequest field, applying the masking policy.
+func (e *Event) WithHTTPRequest(r HTTPRequest) *Event {
+	return e.WithField(httpRequestKey, r.fields())
+}
@@ -0,0 +1,134 @@
+package zlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// TestGCPProfile verifies the special fields written by the GCP profile.
+func TestGCPProfile(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{
+		Level:     "debug",
+		Profile:   ProfileGCP,
+		ProjectID: "my-project",
+		Outputs:   []OutputConfig{{Writer: &buf}},
+	})
+	ctx := context.WithValue(context.Background(), TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	logger.Event(logger.Warn().Ctx(ctx)).
+		WithHTTPRequest(HTTPRequest{Method: "GET", URL: "/orders", Status: 200, Latency: 1500 * time.Millisecond}).
+		Msg("slow request")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["severity"] != "WARNING" {
+		t.Errorf("expected WARNING severity, got %v", entry["severity"])
+	}
+	if got := entry[gcpTraceKey]; got != "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected trace %v", got)
+	}
+	if _, ok := entry[traceIDKey]; ok {
+		t.Error("expected trace_id to be replaced")
+	}
+	source, _ := entry[gcpSourceLocationKey].(map[string]interface{})
+	if source["file"] != "profile_test.go" || source["function"] != "TestGCPProfile" || source["line"] == "" {
+		t.Errorf("unexpected sourceLocation %v", source)
+	}
+	request, _ := entry[httpRequestKey].(map[string]interface{})
+	if request["requestMethod"] != "GET" || request["status"] != float64(200) || request["latency"] != "1.5s" {
+		t.Errorf("unexpected httpRequest %v", request)
+	}
+}
+
+// TestGCPFields verifies the rewrite of the fields for Google Cloud Logging.
+func TestGCPFields(t *testing.T) {
+	cases := []struct {
+		name      string
+		projectID string
+		line      string
+		want      string
+	}{
+		{"Severities", "", `{"severity":"fatal","message":"m"}`, `{"severity":"CRITICAL","message":"m"}`},
+		{"UnknownSeverity", "", `{"severity":"","message":"m"}`, `{"severity":"DEFAULT","message":"m"}`},
+		{"TraceWithoutProject", "", `{"trace_id":"abc","span_id":"def"}`,
+			`{"trace_id":"abc","logging.googleapis.com/spanId":"def"}`},
+		{"TraceNameWithoutProject", "", `{"trace_id":"projects/p/traces/abc"}`,
+			`{"logging.googleapis.com/trace":"projects/p/traces/abc"}`},
+		{"TraceSampled", "p", `{"trace_id":"abc","trace_flags":"01"}`,
+			`{"logging.googleapis.com/trace":"projects/p/traces/abc","logging.googleapis.com/trace_sampled":true}`},
+		{"TraceNotSampled", "", `{"trace_flags":"00"}`, `{"logging.googleapis.com/trace_sampled":false}`},
+		{"SourceLocation", "p", `{"file":"main.go:12","func":"main","message":"m"}`,
+			`{"logging.googleapis.com/sourceLocation":{"file":"main.go","line":"12","function":"main"},"message":"m"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &profileWriter{rewrite: gcpFields(tc.projectID, "severity")}
+			if got := string(w.rewriteLine([]byte(tc.line))); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestProfileWriterLevel verifies that the profile writer keeps the level for a filtered output.
+func TestProfileWriterLevel(t *testing.T) {
+	var buf bytes.Buffer
+	filtered := &zerolog.FilteredLevelWriter{Writer: zerolog.LevelWriterAdapter{Writer: &buf}, Level: zerolog.ErrorLevel}
+	w := newProfileWriter(filtered, ProfileGCP, "", defaultFieldNames)
+	lw, ok := w.(zerolog.LevelWriter)
+	if !ok {
+		t.Fatalf("expected a zerolog.LevelWriter, got %T", w)
+	}
+	_, _ = lw.WriteLevel(zerolog.InfoLevel, []byte(`{"severity":"info"}`+"\n"))
+	_, _ = lw.WriteLevel(zerolog.ErrorLevel, []byte(`{"severity":"error"}`+"\n"))
+	if got := buf.String(); got != `{"severity":"ERROR"}`+"\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// TestECSFields verifies the rewrite of the fields for the Elastic Common Schema.
+func TestECSFields(t *testing.T) {
+	w := &profileWriter{rewrite: ecsFields("timestamp", "severity")}
+	line := `{"severity":"error","appName":"orders","trace_id":"abc","error":"boom","file":"main.go:12","func":"main",` +
+		`"timestamp":"2024-01-01T00:00:00Z","message":"failed"}`
+	want := `{"log.level":"error","service.name":"orders","trace.id":"abc","error.message":"boom",` +
+		`"log.origin.file.name":"main.go","log.origin.file.line":12,"log.origin.function":"main",` +
+		`"@timestamp":"2024-01-01T00:00:00Z","message":"failed","ecs.version":"` + ecsVersion + `"}`
+	if got := string(w.rewriteLine([]byte(line))); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// TestDatadogFields verifies the rewrite of the fields for the reserved attributes of Datadog.
+func TestDatadogFields(t *testing.T) {
+	w := &profileWriter{rewrite: datadogFields("severity")}
+	line := `{"severity":"warn","appName":"orders","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736",` +
+		`"span_id":"00f067aa0ba902b7","message":"slow"}`
+	want := `{"status":"warn","service":"orders","dd.trace_id":"11803532876627986230",` +
+		`"dd.span_id":"67667974448284343","message":"slow"}`
+	if got := string(w.rewriteLine([]byte(line))); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// TestDatadogID verifies the conversion of the hex ids, leaving the other ids untouched.
+func TestDatadogID(t *testing.T) {
+	cases := map[string]string{
+		"00f067aa0ba902b7":                 "67667974448284343",
+		"4bf92f3577b34da6a3ce929d0e0e4736": "11803532876627986230",
+		"12345":                            "12345",
+		"zzzzzzzzzzzzzzzz":                 "zzzzzzzzzzzzzzzz",
+	}
+	for id, want := range cases {
+		if got := datadogID(id); got != want {
+			t.Errorf("datadogID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
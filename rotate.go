@@ -0,0 +1,316 @@
+package zlogs
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp added to the name of a rotated file.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// compressSuffix is the extension added to a compressed rotated file.
+const compressSuffix = ".gz"
+
+// RotationConfig configures the rotation of a file output. A file rotates when it would exceed MaxSize bytes or
+// when it has been open for Interval; leaving both to zero disables the rotation.
+type RotationConfig struct {
+	MaxSize  int64
+	Interval time.Duration
+	// MaxBackups is the number of rotated files to keep, and MaxAge the age after which they are deleted.
+	// Zero keeps them all.
+	MaxBackups int
+	MaxAge     time.Duration
+	// Compress gzips the rotated files in the background.
+	Compress bool
+	// ReopenOnSignal reopens the file on SIGHUP, after an external tool such as logrotate moved it.
+	ReopenOnSignal bool
+}
+
+// enabled reports whether the rotation is configured.
+func (c RotationConfig) enabled() bool {
+	return c.MaxSize > 0 || c.Interval > 0
+}
+
+// RotatingFile is an io.Writer appending to a file that it rotates by size and age, keeping a limited number of
+// backups and compressing them in the background. It is safe for concurrent use.
+type RotatingFile struct {
+	path   string
+	config RotationConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	closed   bool
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	mill     chan struct{}
+	millDone chan struct{}
+	stop     func()
+}
+
+// NewRotatingFile opens or creates the file at path, rotating it according to the config.
+func NewRotatingFile(path string, config RotationConfig) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:     path,
+		config:   config,
+		now:      time.Now,
+		mill:     make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+		stop:     func() {},
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	go r.runMill()
+	if config.ReopenOnSignal {
+		r.stop = notifyReopen(r)
+	}
+	return r, nil
+}
+
+// Write appends p to the file, rotating it first when p would exceed the maximum size or the file is too old.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it with a timestamp and opens a new one.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	return r.rotate()
+}
+
+// Reopen closes and reopens the file at its path, without renaming it.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Close closes the file and waits for the background compression and cleanup to finish.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	err := r.closeFile()
+	r.stop()
+	close(r.mill)
+	r.mu.Unlock()
+	<-r.millDone
+	return err
+}
+
+// shouldRotate reports whether writing n more bytes requires a rotation first.
+func (r *RotatingFile) shouldRotate(n int64) bool {
+	if r.config.MaxSize > 0 && r.size > 0 && r.size+n > r.config.MaxSize {
+		return true
+	}
+	return r.config.Interval > 0 && !r.now().Before(r.openedAt.Add(r.config.Interval))
+}
+
+// open opens the file at its path for appending.
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	r.openedAt = r.now()
+	return nil
+}
+
+// closeFile closes the current file, if any.
+func (r *RotatingFile) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// rotate renames the current file to a backup, opens a new one and wakes up the background mill.
+func (r *RotatingFile) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	if err := os.Rename(r.path, r.backupName(r.now())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	select {
+	case r.mill <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// backupName returns a free backup name for the file, e.g. "app-2006-01-02T15-04-05.000.log".
+func (r *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := r.backupPrefixExt()
+	for {
+		name := prefix + t.UTC().Format(backupTimeFormat) + ext
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			if _, err := os.Stat(name + compressSuffix); os.IsNotExist(err) {
+				return name
+			}
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// backupPrefixExt splits the path into the prefix and the extension of its backups.
+func (r *RotatingFile) backupPrefixExt() (string, string) {
+	ext := filepath.Ext(r.path)
+	return strings.TrimSuffix(r.path, ext) + "-", ext
+}
+
+// runMill compresses and cleans up the backups each time the file rotates, until the file is closed.
+func (r *RotatingFile) runMill() {
+	defer close(r.millDone)
+	for range r.mill {
+		_ = r.millBackups()
+	}
+}
+
+// backup is a rotated file and the time it was rotated.
+type backup struct {
+	path      string
+	rotatedAt time.Time
+}
+
+// millBackups deletes the backups beyond MaxBackups or older than MaxAge and compresses the remaining ones.
+func (r *RotatingFile) millBackups() error {
+	backups, err := r.listBackups()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i, b := range backups {
+		expired := r.config.MaxAge > 0 && r.now().Sub(b.rotatedAt) > r.config.MaxAge
+		if (r.config.MaxBackups > 0 && i >= r.config.MaxBackups) || expired {
+			if err := os.Remove(b.path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if r.config.Compress && !strings.HasSuffix(b.path, compressSuffix) {
+			if err := compressFile(b.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("zlogs: rotated files cleanup: %v", errs)
+	}
+	return nil
+}
+
+// listBackups returns the rotated files of the path, newest first.
+func (r *RotatingFile) listBackups() ([]backup, error) {
+	prefix, ext := r.backupPrefixExt()
+	entries, err := os.ReadDir(filepath.Dir(r.path))
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(prefix)
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, base), compressSuffix), ext)
+		rotatedAt, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(filepath.Dir(r.path), name), rotatedAt: rotatedAt})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotatedAt.After(backups[j].rotatedAt)
+	})
+	return backups, nil
+}
+
+// compressFile gzips the file into a ".gz" file and removes the original.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + compressSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path+compressSuffix); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,176 @@
+package zlogs
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRotatingFileBySize verifies that the file rotates before exceeding its maximum size and keeps MaxBackups.
+func TestRotatingFileBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	file, err := NewRotatingFile(path, RotationConfig{MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"entry-1\n", "entry-2\n", "entry-3\n", "entry-4\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, path); got != "entry-4\n" {
+		t.Errorf("expected the last entry in the current file, got %q", got)
+	}
+	backups, err := file.listBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %v", backups)
+	}
+	if got := readFile(t, backups[0].path); got != "entry-3\n" {
+		t.Errorf("expected the newest backup first, got %q", got)
+	}
+}
+
+// TestRotatingFileByInterval verifies that the file rotates once it has been open for the interval.
+func TestRotatingFileByInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := NewRotatingFile(path, RotationConfig{Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	file.now = func() time.Time { return now }
+	file.openedAt = now
+
+	_, _ = file.Write([]byte("first\n"))
+	now = now.Add(time.Hour)
+	_, _ = file.Write([]byte("second\n"))
+
+	if got := readFile(t, path); got != "second\n" {
+		t.Errorf("expected a new file after the interval, got %q", got)
+	}
+	backup := filepath.Join(filepath.Dir(path), "app-2024-01-01T11-00-00.000.log")
+	if got := readFile(t, backup); got != "first\n" {
+		t.Errorf("expected the first entry in the backup, got %q", got)
+	}
+}
+
+// TestRotatingFileMaxAge verifies that the backups older than MaxAge are deleted.
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	old := filepath.Join(dir, "app-2000-01-01T00-00-00.000.log.gz")
+	if err := os.WriteFile(old, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := NewRotatingFile(path, RotationConfig{MaxSize: 1024, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expected the expired backup to be deleted, got %v", err)
+	}
+	if backups, _ := file.listBackups(); len(backups) != 1 {
+		t.Errorf("expected the fresh backup to be kept, got %v", backups)
+	}
+}
+
+// TestRotatingFileCompress verifies that the rotated files are gzip-compressed in the background.
+func TestRotatingFileCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := NewRotatingFile(path, RotationConfig{MaxSize: 1024, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.Write([]byte("compressed entry\n"))
+	if err := file.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := file.listBackups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0].path, compressSuffix) {
+		t.Fatalf("expected a single compressed backup, got %v", backups)
+	}
+	f, err := os.Open(backups[0].path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(gz)
+	if string(content) != "compressed entry\n" {
+		t.Errorf("got %q", content)
+	}
+}
+
+// TestRotatingFileReopen verifies that the file is recreated at its path after being moved, as logrotate does.
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	file, err := NewRotatingFile(path, RotationConfig{MaxSize: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	_, _ = file.Write([]byte("before\n"))
+	if err := os.Rename(path, filepath.Join(dir, "app.log.1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.Write([]byte("after\n"))
+
+	if got := readFile(t, path); got != "after\n" {
+		t.Errorf("expected a new file after reopening, got %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log.1")); got != "before\n" {
+		t.Errorf("expected the moved file untouched, got %q", got)
+	}
+}
+
+// TestRotatingOutput verifies that a file output with a rotation config uses a RotatingFile.
+func TestRotatingOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	out := openOutput(path, RotationConfig{MaxSize: 1024})
+	file, ok := out.(*RotatingFile)
+	if !ok {
+		t.Fatalf("expected a *RotatingFile, got %T", out)
+	}
+	_ = file.Close()
+}
+
+// readFile returns the content of a file, failing the test when it cannot be read.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
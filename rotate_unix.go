@@ -0,0 +1,30 @@
+//go:build !windows
+
+package zlogs
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReopen reopens the file each time the process receives SIGHUP. It returns the function stopping it.
+func notifyReopen(r *RotatingFile) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-signals:
+				_ = r.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
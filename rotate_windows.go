@@ -0,0 +1,8 @@
+//go:build windows
+
+package zlogs
+
+// notifyReopen does nothing, as there is no SIGHUP on Windows.
+func notifyReopen(*RotatingFile) func() {
+	return func() {}
+}
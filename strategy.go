@@ -0,0 +1,139 @@
+package zlogs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaskStrategy describes how a sensitive value is masked. Besides the constants below, KeepFirst, KeepLast and
+// FixedStars build the parameterised strategies, which can also be written as "first4", "last4" and "stars8".
+type MaskStrategy string
+
+// MaskRedact replaces the value with the redacted value.
+// MaskEmail masks only the local part of an email address.
+// MaskHash replaces the value with its SHA-256 hash, keyed with MaskingConfig.HashSecret when set.
+// MaskDrop removes the field from the log entry, or the matched text from a string for a detector.
+// MaskTokenize replaces the value with a stable token stored in MaskingConfig.TokenVault, to be recovered later.
+const (
+	MaskRedact   MaskStrategy = "redact"
+	MaskEmail    MaskStrategy = "email"
+	MaskHash     MaskStrategy = "hash"
+	MaskDrop     MaskStrategy = "drop"
+	MaskTokenize MaskStrategy = "tokenize"
+)
+
+// KeepFirst returns a strategy that keeps the first n characters of the value and stars the rest.
+func KeepFirst(n int) MaskStrategy {
+	return MaskStrategy("first" + strconv.Itoa(n))
+}
+
+// KeepLast returns a strategy that keeps the last n characters of the value and stars the rest.
+func KeepLast(n int) MaskStrategy {
+	return MaskStrategy("last" + strconv.Itoa(n))
+}
+
+// FixedStars returns a strategy that replaces the value with n stars, hiding its length.
+func FixedStars(n int) MaskStrategy {
+	return MaskStrategy("stars" + strconv.Itoa(n))
+}
+
+// maskFunc masks a single value. It returns false when the value must be dropped.
+type maskFunc func(value string) (string, bool)
+
+// redact is the default maskFunc, replacing any value with the redacted value.
+func redact(string) (string, bool) {
+	return redactedValue, true
+}
+
+// newMaskFunc parses a strategy of the config into its maskFunc. It panics on an invalid strategy, so that a
+// misconfigured masking never goes unnoticed.
+func newMaskFunc(strategy MaskStrategy, secret string, vault TokenVault) maskFunc {
+	mask, err := parseMaskFunc(strategy, secret, vault)
+	if err != nil {
+		panic(err.Error())
+	}
+	return mask
+}
+
+// parseMaskFunc parses a strategy into its maskFunc, using the secret for hashing and the vault for tokenization.
+func parseMaskFunc(strategy MaskStrategy, secret string, vault TokenVault) (maskFunc, error) {
+	name := strings.ToLower(string(strategy))
+	switch name {
+	case "", string(MaskRedact):
+		return redact, nil
+	case string(MaskEmail):
+		return maskEmail, nil
+	case string(MaskHash):
+		return hashWith(secret), nil
+	case string(MaskDrop):
+		return func(string) (string, bool) { return "", false }, nil
+	case string(MaskTokenize):
+		if vault == nil {
+			return nil, errors.New("zlogs: tokenize masking strategy requires a TokenVault")
+		}
+		return tokenizeWith(vault), nil
+	}
+	for _, prefix := range []string{"first", "last", "stars"} {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		n, err := strconv.Atoi(name[len(prefix):])
+		if err != nil || n < 0 {
+			break
+		}
+		switch prefix {
+		case "first":
+			return func(value string) (string, bool) { return keepFirst(value, n), true }, nil
+		case "last":
+			return func(value string) (string, bool) { return keepLast(value, n), true }, nil
+		default:
+			return func(string) (string, bool) { return strings.Repeat("*", n), true }, nil
+		}
+	}
+	return nil, fmt.Errorf("zlogs: unknown masking strategy %q", strategy)
+}
+
+// keepFirst keeps the first n runes of value and replaces the others with stars.
+func keepFirst(value string, n int) string {
+	runes := []rune(value)
+	if n >= len(runes) {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:n]) + strings.Repeat("*", len(runes)-n)
+}
+
+// keepLast keeps the last n runes of value and replaces the others with stars.
+func keepLast(value string, n int) string {
+	runes := []rune(value)
+	if n >= len(runes) {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", len(runes)-n) + string(runes[len(runes)-n:])
+}
+
+// maskEmail redacts the local part of an email address and keeps its domain.
+func maskEmail(value string) (string, bool) {
+	at := strings.LastIndex(value, "@")
+	if at < 0 {
+		return redactedValue, true
+	}
+	return redactedValue + value[at:], true
+}
+
+// hashWith returns a maskFunc producing the hex HMAC-SHA256 of the value, or its plain SHA-256 without a secret.
+func hashWith(secret string) maskFunc {
+	return func(value string) (string, bool) {
+		if secret == "" {
+			sum := sha256.Sum256([]byte(value))
+			return hex.EncodeToString(sum[:]), true
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil)), true
+	}
+}
@@ -0,0 +1,102 @@
+package zlogs
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMaskStrategies verifies the masking of a value by each strategy.
+func TestMaskStrategies(t *testing.T) {
+	cases := []struct {
+		name     string
+		strategy MaskStrategy
+		secret   string
+		input    string
+		want     string
+		keep     bool
+	}{
+		{"Redact", MaskRedact, "", "4111111111111111", redactedValue, true},
+		{"DefaultRedact", "", "", "secret", redactedValue, true},
+		{"KeepLast", KeepLast(4), "", "4111111111111111", "************1111", true},
+		{"KeepFirst", KeepFirst(1), "", "John", "J***", true},
+		{"KeepFirstShortValue", KeepFirst(4), "", "Jo", "**", true},
+		{"TagSyntax", "last2", "", "12345", "***45", true},
+		{"FixedStars", FixedStars(5), "", "P@ssw0rd", "*****", true},
+		{"Email", MaskEmail, "", "john.doe@example.com", "***@example.com", true},
+		{"HashWithoutSecret", MaskHash, "", "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", true},
+		{"HashWithSecret", MaskHash, "key", "The quick brown fox jumps over the lazy dog", "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", true},
+		{"Drop", MaskDrop, "", "secret", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, keep := newMaskFunc(tc.strategy, tc.secret, nil)(tc.input)
+			if got != tc.want || keep != tc.keep {
+				t.Errorf("got (%q, %v), want (%q, %v)", got, keep, tc.want, tc.keep)
+			}
+		})
+	}
+}
+
+// TestMaskStrategiesByField verifies that strategies are applied per field and per detector.
+func TestMaskStrategiesByField(t *testing.T) {
+	logger := New(&Config{
+		Level: "debug",
+		Masking: MaskingConfig{
+			Enabled:   true,
+			Detectors: []string{DetectorPAN},
+			Strategies: map[string]MaskStrategy{
+				"card_number": KeepLast(4),
+				"firstname":   KeepFirst(1),
+				"password":    MaskDrop,
+				DetectorPAN:   KeepLast(4),
+			},
+		},
+	})
+
+	got := logger.maskFields(nil, map[string]interface{}{
+		"card_number": "4111111111111111",
+		"firstname":   "John",
+		"password":    "P@ssw0rd",
+		"lastname":    "Doe",
+		"note":        "paid with 4111111111111111",
+	})
+	want := map[string]interface{}{
+		"card_number": "************1111",
+		"firstname":   "J***",
+		"lastname":    redactedValue,
+		"note":        "paid with ************1111",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestMaskStrategyPointer verifies that a strategy masks the value a pointer refers to, not its address.
+func TestMaskStrategyPointer(t *testing.T) {
+	logger := New(&Config{
+		Level:   "debug",
+		Masking: MaskingConfig{Enabled: true, Strategies: map[string]MaskStrategy{"card_number": KeepLast(4)}},
+	})
+	card := "4111111111111111"
+	want := "************1111"
+
+	if got := logger.maskFields(nil, map[string]interface{}{"card_number": &card}); got["card_number"] != want {
+		t.Errorf("got %v, want %q", got["card_number"], want)
+	}
+	payload := struct {
+		Card *string `json:"card_number"`
+	}{&card}
+	if got := logWithField(t, logger, "payload", payload)["payload"]; !reflect.DeepEqual(got, map[string]interface{}{"card_number": want}) {
+		t.Errorf("got %v, want %q", got, want)
+	}
+}
+
+// TestUnknownMaskStrategy verifies that an unknown strategy is reported at construction.
+func TestUnknownMaskStrategy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	newMaskFunc("partial", "", nil)
+}
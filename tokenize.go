@@ -0,0 +1,307 @@
+package zlogs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sync"
+)
+
+// tokenPrefix starts every token produced by the MaskTokenize strategy.
+const tokenPrefix = "tok_"
+
+// tokenPattern matches the tokens produced by the MaskTokenize strategy.
+var tokenPattern = regexp.MustCompile(`\b` + tokenPrefix + `[0-9a-f]{24}\b`)
+
+// ErrTokenNotFound is returned when a token is unknown to a TokenVault.
+var ErrTokenNotFound = errors.New("zlogs: token not found")
+
+// TokenVault stores the values replaced by the MaskTokenize strategy so that they can be recovered later.
+// Tokenize must return the same token for the same value.
+type TokenVault interface {
+	Tokenize(value string) (string, error)
+	Detokenize(token string) (string, error)
+}
+
+// DefaultVaultCapacity is the number of values kept in memory by a vault created without a capacity.
+const DefaultVaultCapacity = 100000
+
+// MemoryVault is a TokenVault keeping the values in memory, safe for concurrent use. It keeps at most its capacity of
+// values, evicting the oldest ones, whose tokens can no longer be detokenized.
+type MemoryVault struct {
+	key      []byte
+	mu       sync.RWMutex
+	values   map[string]string
+	capacity int
+	order    []string
+	next     int
+}
+
+// NewMemoryVault creates a MemoryVault holding DefaultVaultCapacity values, whose tokens are derived from the key, so
+// that they are stable across restarts. An empty key is replaced by a random one.
+func NewMemoryVault(key string) *MemoryVault {
+	return NewMemoryVaultSize(key, DefaultVaultCapacity)
+}
+
+// NewMemoryVaultSize creates a MemoryVault like NewMemoryVault, holding at most capacity values, or
+// DefaultVaultCapacity when capacity is not positive.
+func NewMemoryVaultSize(key string, capacity int) *MemoryVault {
+	tokenKey := sha256.Sum256([]byte("zlogs-token:" + key))
+	if key == "" {
+		_, _ = rand.Read(tokenKey[:])
+	}
+	if capacity <= 0 {
+		capacity = DefaultVaultCapacity
+	}
+	return &MemoryVault{
+		key:      tokenKey[:],
+		values:   make(map[string]string),
+		capacity: capacity,
+	}
+}
+
+// Tokenize returns the token of the value, storing the value.
+func (v *MemoryVault) Tokenize(value string) (string, error) {
+	token := v.token(value)
+	v.mu.Lock()
+	v.store(token, value)
+	v.mu.Unlock()
+	return token, nil
+}
+
+// Detokenize returns the value of a token, or ErrTokenNotFound.
+func (v *MemoryVault) Detokenize(token string) (string, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	value, ok := v.values[token]
+	if !ok {
+		return "", ErrTokenNotFound
+	}
+	return value, nil
+}
+
+// Len returns the number of values held by the vault.
+func (v *MemoryVault) Len() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.values)
+}
+
+// Reset removes every value from the vault.
+func (v *MemoryVault) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.values = make(map[string]string)
+	v.order = nil
+	v.next = 0
+}
+
+// store stores the value of a token, evicting the oldest value when the vault is full. The caller holds the lock.
+func (v *MemoryVault) store(token, value string) {
+	if _, ok := v.values[token]; ok {
+		v.values[token] = value
+		return
+	}
+	if len(v.order) < v.capacity {
+		v.order = append(v.order, token)
+	} else {
+		delete(v.values, v.order[v.next])
+		v.order[v.next] = token
+		v.next = (v.next + 1) % v.capacity
+	}
+	v.values[token] = value
+}
+
+// token derives the stable token of a value.
+func (v *MemoryVault) token(value string) string {
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write([]byte(value))
+	return tokenPrefix + hex.EncodeToString(mac.Sum(nil))[:24]
+}
+
+// contains reports whether the token is already stored.
+func (v *MemoryVault) contains(token string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	_, ok := v.values[token]
+	return ok
+}
+
+// FileVault is a TokenVault appending the values to a file, each record encrypted with AES-GCM, and keeping the
+// latest DefaultVaultCapacity of them in memory for lookups. The tokens evicted from memory are looked up in the
+// file, which keeps every value until Reset. It is safe for concurrent use.
+type FileVault struct {
+	memory *MemoryVault
+	aead   cipher.AEAD
+	mu     sync.Mutex
+	file   *os.File
+}
+
+// NewFileVault opens or creates the vault file at path, decrypting its records with the key.
+func NewFileVault(path, key string) (*FileVault, error) {
+	if key == "" {
+		return nil, errors.New("zlogs: file vault requires a key")
+	}
+	encryptionKey := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(encryptionKey[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &FileVault{
+		memory: NewMemoryVault(key),
+		aead:   aead,
+		file:   file,
+	}
+	err = v.records(file, func(token, value string) bool {
+		v.memory.store(token, value)
+		return true
+	})
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return v, nil
+}
+
+// records decrypts the records of the vault file read from r, passing them to fn until it returns false.
+func (v *FileVault) records(r io.Reader, fn func(token, value string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		record, err := base64.StdEncoding.DecodeString(scanner.Text())
+		if err != nil || len(record) < v.aead.NonceSize() {
+			return fmt.Errorf("zlogs: corrupted vault record: %w", err)
+		}
+		nonce, sealed := record[:v.aead.NonceSize()], record[v.aead.NonceSize():]
+		plain, err := v.aead.Open(nil, nonce, sealed, nil)
+		if err != nil {
+			return fmt.Errorf("zlogs: cannot decrypt vault record: %w", err)
+		}
+		token, value, found := bytes.Cut(plain, []byte{'\n'})
+		if !found {
+			return errors.New("zlogs: corrupted vault record")
+		}
+		if !fn(string(token), string(value)) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Tokenize returns the token of the value, appending the encrypted value to the file when it is not in memory.
+func (v *FileVault) Tokenize(value string) (string, error) {
+	token := v.memory.token(value)
+	if v.memory.contains(token) {
+		return token, nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.memory.contains(token) {
+		return token, nil
+	}
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	record := v.aead.Seal(nonce, nonce, []byte(token+"\n"+value), nil)
+	if _, err := v.file.WriteString(base64.StdEncoding.EncodeToString(record) + "\n"); err != nil {
+		return "", err
+	}
+	return v.memory.Tokenize(value)
+}
+
+// Detokenize returns the value of a token, looking it up in the file when it was evicted from memory, or
+// ErrTokenNotFound.
+func (v *FileVault) Detokenize(token string) (string, error) {
+	if value, err := v.memory.Detokenize(token); err == nil {
+		return value, nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var value string
+	found := false
+	err := v.records(io.NewSectionReader(v.file, 0, math.MaxInt64), func(recordToken, recordValue string) bool {
+		value, found = recordValue, recordToken == token
+		return !found
+	})
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", ErrTokenNotFound
+	}
+	_, _ = v.memory.Tokenize(value)
+	return value, nil
+}
+
+// Len returns the number of values held in memory by the vault.
+func (v *FileVault) Len() int {
+	return v.memory.Len()
+}
+
+// Reset removes every value from the vault, truncating its file.
+func (v *FileVault) Reset() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err := v.file.Truncate(0); err != nil {
+		return err
+	}
+	v.memory.Reset()
+	return nil
+}
+
+// Close closes the vault file.
+func (v *FileVault) Close() error {
+	return v.file.Close()
+}
+
+// tokenizeWith returns a maskFunc replacing the value with its token, redacting it when the vault fails.
+func tokenizeWith(vault TokenVault) maskFunc {
+	return func(value string) (string, bool) {
+		token, err := vault.Tokenize(value)
+		if err != nil {
+			return redactedValue, true
+		}
+		return token, true
+	}
+}
+
+// DetokenizeLine replaces every token of a log line with the value stored in the vault. Within a JSON line,
+// the values are escaped as JSON strings. Tokens unknown to the vault are left in place.
+func DetokenizeLine(line []byte, vault TokenVault) []byte {
+	isJSON := len(bytes.TrimSpace(line)) > 0 && bytes.TrimSpace(line)[0] == '{'
+	return tokenPattern.ReplaceAllFunc(line, func(token []byte) []byte {
+		value, err := vault.Detokenize(string(token))
+		if err != nil {
+			return token
+		}
+		if isJSON {
+			quoted := marshalJSON(value)
+			return quoted[1 : len(quoted)-1]
+		}
+		return []byte(value)
+	})
+}
@@ -0,0 +1,174 @@
+package zlogs
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// TestMemoryVault verifies that tokens are stable per key and can be detokenized.
+func TestMemoryVault(t *testing.T) {
+	vault := NewMemoryVault("key")
+	token, err := vault.Tokenize("4111111111111111")
+	if err != nil || !tokenPattern.MatchString(token) {
+		t.Fatalf("unexpected token %q, %v", token, err)
+	}
+	if again, _ := NewMemoryVault("key").Tokenize("4111111111111111"); again != token {
+		t.Errorf("expected stable token, got %q and %q", token, again)
+	}
+	if other, _ := NewMemoryVault("other").Tokenize("4111111111111111"); other == token {
+		t.Error("expected a different token for a different key")
+	}
+	if value, err := vault.Detokenize(token); err != nil || value != "4111111111111111" {
+		t.Errorf("got %q, %v", value, err)
+	}
+	if _, err := vault.Detokenize("tok_000000000000000000000000"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound, got %v", err)
+	}
+}
+
+// TestFileVault verifies that the values are persisted encrypted and recovered with the key only.
+func TestFileVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault")
+	vault, err := NewFileVault(path, "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := vault.Tokenize("john@doe.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vault.Tokenize("john@doe.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := vault.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileVault(path, "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if value, err := reopened.Detokenize(token); err != nil || value != "john@doe.com" {
+		t.Errorf("got %q, %v", value, err)
+	}
+	if _, err := NewFileVault(path, "wrong"); err == nil {
+		t.Error("expected an error with a wrong key")
+	}
+	if _, err := NewFileVault(path, ""); err == nil {
+		t.Error("expected an error without a key")
+	}
+}
+
+// TestMemoryVaultCapacity verifies that a full vault evicts its oldest values, and that it can be emptied.
+func TestMemoryVaultCapacity(t *testing.T) {
+	vault := NewMemoryVaultSize("key", 2)
+	first, _ := vault.Tokenize("first")
+	second, _ := vault.Tokenize("second")
+	_, _ = vault.Tokenize("second")
+	third, _ := vault.Tokenize("third")
+	if vault.Len() != 2 {
+		t.Errorf("expected 2 values, got %d", vault.Len())
+	}
+	if _, err := vault.Detokenize(first); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected the oldest value evicted, got %v", err)
+	}
+	for token, want := range map[string]string{second: "second", third: "third"} {
+		if value, err := vault.Detokenize(token); err != nil || value != want {
+			t.Errorf("got %q, %v, want %q", value, err, want)
+		}
+	}
+	vault.Reset()
+	if _, err := vault.Detokenize(third); vault.Len() != 0 || !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected an empty vault, got %d values", vault.Len())
+	}
+}
+
+// TestFileVaultEviction verifies that the values evicted from memory are found in the file until Reset.
+func TestFileVaultEviction(t *testing.T) {
+	vault, err := NewFileVault(filepath.Join(t.TempDir(), "vault"), "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vault.Close()
+	vault.memory = NewMemoryVaultSize("s3cr3t", 1)
+	first, _ := vault.Tokenize("first")
+	_, _ = vault.Tokenize("second")
+	if vault.Len() != 1 {
+		t.Errorf("expected 1 value in memory, got %d", vault.Len())
+	}
+	if value, err := vault.Detokenize(first); err != nil || value != "first" {
+		t.Errorf("got %q, %v", value, err)
+	}
+	if err := vault.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vault.Detokenize(first); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound after Reset, got %v", err)
+	}
+}
+
+// TestTokenizeStrategy verifies that tokenized fields can be recovered from the log line.
+func TestTokenizeStrategy(t *testing.T) {
+	vault := NewMemoryVault("key")
+	logger := New(&Config{
+		Level: "debug",
+		Masking: MaskingConfig{
+			Enabled:    true,
+			Strategies: map[string]MaskStrategy{"password": MaskTokenize},
+			TokenVault: vault,
+		},
+	})
+
+	var buf bytes.Buffer
+	zl := zerolog.New(&buf)
+	logger.Event(zl.Info()).WithField("password", `P@"ss`).Msg("")
+	line := buf.Bytes()
+	if bytes.Contains(line, []byte("P@")) || !tokenPattern.Match(line) {
+		t.Fatalf("expected a tokenized password, got %q", line)
+	}
+	if got := string(DetokenizeLine(line, vault)); !strings.Contains(got, `"password":"P@\"ss"`) {
+		t.Errorf("expected the detokenized password, got %q", got)
+	}
+	if got := string(DetokenizeLine([]byte("tok_000000000000000000000000"), vault)); got != "tok_000000000000000000000000" {
+		t.Errorf("expected unknown token to be kept, got %q", got)
+	}
+}
+
+// TestTokenizeDisabledEvent verifies that an entry filtered out by the level stores nothing in the vault.
+func TestTokenizeDisabledEvent(t *testing.T) {
+	vault := NewMemoryVault("key")
+	var buf bytes.Buffer
+	logger := New(&Config{
+		Level: "info",
+		Masking: MaskingConfig{
+			Enabled:    true,
+			Strategies: map[string]MaskStrategy{"password": MaskTokenize},
+			TokenVault: vault,
+		},
+		Outputs: []OutputConfig{{Writer: &buf}},
+	})
+	logger.Event(logger.Debug()).
+		WithField("password", "a").
+		WithFields(map[string]interface{}{"password": "b"}).
+		Msgf("password=%s", "c")
+
+	if vault.Len() != 0 || buf.Len() != 0 {
+		t.Errorf("expected an empty vault, got %d values", vault.Len())
+	}
+}
+
+// TestTokenizeWithoutVault verifies that the tokenize strategy requires a vault.
+func TestTokenizeWithoutVault(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	newMaskingPolicy(MaskingConfig{Strategies: map[string]MaskStrategy{"password": MaskTokenize}})
+}
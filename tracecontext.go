@@ -0,0 +1,162 @@
+package zlogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// traceFlagsKey is the key of the trace flags added by DefaultContextExtractors.
+const traceFlagsKey = "trace_flags"
+
+// spanContextKey is the context key of the SpanContext set by WithSpanContext and WithTraceparent.
+const spanContextKey contextKey = loggerKey + 1
+
+// errInvalidTraceparent is returned by ParseTraceparent for a value that is not a valid W3C traceparent.
+var errInvalidTraceparent = errors.New("zlogs: invalid traceparent")
+
+// SpanContext identifies the span of an entry, to correlate the logs with the traces. It is implemented by the
+// values returned by ParseTraceparent, and can wrap the span context of a tracing library, e.g. with
+// TraceID().String() of an OpenTelemetry trace.SpanContext, so that logging does not depend on its SDK.
+type SpanContext interface {
+	// TraceID returns the trace ID as 32 lowercase hex characters.
+	TraceID() string
+	// SpanID returns the span ID as 16 lowercase hex characters.
+	SpanID() string
+	// TraceFlags returns the W3C trace flags, 1 when the trace is sampled.
+	TraceFlags() byte
+}
+
+// traceparent is a SpanContext parsed from a W3C traceparent.
+type traceparent struct {
+	traceID string
+	spanID  string
+	flags   byte
+}
+
+func (t traceparent) TraceID() string  { return t.traceID }
+func (t traceparent) SpanID() string   { return t.spanID }
+func (t traceparent) TraceFlags() byte { return t.flags }
+
+// ParseTraceparent parses a W3C traceparent header such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". Later versions are accepted as long as they start with
+// the fields of version 00.
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return nil, errInvalidTraceparent
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHexID(version, 2) || !isHexID(traceID, 32) || !isHexID(spanID, 16) || !isHexID(flags, 2) {
+		return nil, errInvalidTraceparent
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return nil, errInvalidTraceparent
+	}
+	parsed, _ := strconv.ParseUint(flags, 16, 8)
+	return traceparent{traceID: traceID, spanID: spanID, flags: byte(parsed)}, nil
+}
+
+// FormatTraceparent returns the W3C traceparent header of a SpanContext.
+func FormatTraceparent(sc SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID(), sc.SpanID(), sc.TraceFlags())
+}
+
+// WithSpanContext returns a copy of the context carrying the SpanContext, logged as trace_id, span_id and
+// trace_flags.
+func WithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey, sc)
+}
+
+// WithTraceparent returns a copy of the context carrying the SpanContext of a W3C traceparent header, or the
+// context itself when the header is not valid.
+func WithTraceparent(ctx context.Context, header string) context.Context {
+	sc, err := ParseTraceparent(header)
+	if err != nil {
+		return ctx
+	}
+	return WithSpanContext(ctx, sc)
+}
+
+// SpanContextFromContext returns the SpanContext carried by the context: the one set by WithSpanContext or
+// WithTraceparent, or else a value under the TraceID key that is a SpanContext or a W3C traceparent string.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if sc, ok := ctx.Value(spanContextKey).(SpanContext); ok && sc != nil {
+		return sc, true
+	}
+	switch value := ctx.Value(TraceID).(type) {
+	case SpanContext:
+		return value, value != nil
+	case string:
+		if sc, err := ParseTraceparent(value); err == nil {
+			return sc, true
+		}
+	}
+	return nil, false
+}
+
+// SpanContextExtractors returns the ContextExtractors adding the trace_id, span_id and trace_flags of the SpanContext
+// returned by from, e.g. an adapter around the span context of a tracing library. The flags are written as two hex
+// characters, like in a traceparent.
+func SpanContextExtractors(from func(ctx context.Context) (SpanContext, bool)) []ContextExtractor {
+	return []ContextExtractor{
+		func(ctx context.Context) (string, any, bool) {
+			sc, ok := from(ctx)
+			return traceIDKey, traceIDOf(sc), ok && traceIDOf(sc) != ""
+		},
+		func(ctx context.Context) (string, any, bool) {
+			sc, ok := from(ctx)
+			return spanIDKey, spanIDOf(sc), ok && spanIDOf(sc) != ""
+		},
+		func(ctx context.Context) (string, any, bool) {
+			sc, ok := from(ctx)
+			if !ok || spanIDOf(sc) == "" {
+				return traceFlagsKey, nil, false
+			}
+			return traceFlagsKey, fmt.Sprintf("%02x", sc.TraceFlags()), true
+		},
+	}
+}
+
+// traceIDOrValue returns a ContextExtractor adding the trace ID of the SpanContext of the context, or else the raw
+// value under the TraceID key.
+func traceIDOrValue(spanContext ContextExtractor) ContextExtractor {
+	value := ContextValue(traceIDKey, TraceID)
+	return func(ctx context.Context) (string, any, bool) {
+		if key, id, ok := spanContext(ctx); ok {
+			return key, id, true
+		}
+		return value(ctx)
+	}
+}
+
+// traceIDOf returns the trace ID of a SpanContext, empty when it is nil.
+func traceIDOf(sc SpanContext) string {
+	if sc == nil {
+		return ""
+	}
+	return sc.TraceID()
+}
+
+// spanIDOf returns the span ID of a SpanContext, empty when it is nil.
+func spanIDOf(sc SpanContext) string {
+	if sc == nil {
+		return ""
+	}
+	return sc.SpanID()
+}
+
+// isHexID reports whether id is made of n lowercase hex characters.
+func isHexID(id string, n int) bool {
+	if len(id) != n {
+		return false
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
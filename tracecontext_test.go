@@ -0,0 +1,106 @@
+package zlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// testSpan is a SpanContext wrapping the span context of a tracing library.
+type testSpan struct{}
+
+func (testSpan) TraceID() string  { return "0af7651916cd43dd8448eb211c80319c" }
+func (testSpan) SpanID() string   { return "b7ad6b7169203331" }
+func (testSpan) TraceFlags() byte { return 0 }
+
+// TestParseTraceparent verifies the parsing of the W3C traceparent headers.
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent(testTraceparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID() != "00f067aa0ba902b7" || sc.TraceFlags() != 1 {
+		t.Errorf("unexpected span context %+v", sc)
+	}
+	if got := FormatTraceparent(sc); got != testTraceparent {
+		t.Errorf("got %s", got)
+	}
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Errorf("expected a later version to be accepted, got %v", err)
+	}
+
+	invalid := []string{
+		"",
+		"4bf92f3577b34da6a3ce929d0e0e4736",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+	}
+	for _, value := range invalid {
+		if _, err := ParseTraceparent(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+// TestSpanContextFields verifies the trace fields added from the span context of the context.
+func TestSpanContextFields(t *testing.T) {
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want map[string]interface{}
+	}{
+		{"Traceparent", WithTraceparent(context.Background(), testTraceparent),
+			map[string]interface{}{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7", "trace_flags": "01"}},
+		{"SpanContext", WithSpanContext(context.Background(), testSpan{}),
+			map[string]interface{}{"trace_id": "0af7651916cd43dd8448eb211c80319c", "span_id": "b7ad6b7169203331", "trace_flags": "00"}},
+		{"TraceparentUnderTraceID", WithTraceID(context.Background(), testTraceparent),
+			map[string]interface{}{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7", "trace_flags": "01"}},
+		{"SpanContextUnderTraceID", context.WithValue(context.Background(), TraceID, testSpan{}),
+			map[string]interface{}{"trace_id": "0af7651916cd43dd8448eb211c80319c", "span_id": "b7ad6b7169203331"}},
+		{"PlainTraceID", WithTraceID(context.Background(), "trace-1"),
+			map[string]interface{}{"trace_id": "trace-1", "span_id": nil, "trace_flags": nil}},
+		{"InvalidTraceparent", WithTraceparent(context.Background(), "invalid"),
+			map[string]interface{}{"trace_id": nil, "span_id": nil}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&Config{Outputs: []OutputConfig{{Writer: &buf}}})
+			logger.Info().Ctx(tc.ctx).Msg("traced")
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatal(err)
+			}
+			for key, value := range tc.want {
+				if entry[key] != value {
+					t.Errorf("%s: got %v, want %v", key, entry[key], value)
+				}
+			}
+		})
+	}
+}
+
+// TestSpanContextProfile verifies that the span context is written in the fields of the profile.
+func TestSpanContextProfile(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&Config{Profile: ProfileGCP, ProjectID: "p", Outputs: []OutputConfig{{Writer: &buf}}})
+	logger.Info().Ctx(WithTraceparent(context.Background(), testTraceparent)).Msg("traced")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry[gcpTraceKey] != "projects/p/traces/4bf92f3577b34da6a3ce929d0e0e4736" ||
+		entry[gcpSpanIDKey] != "00f067aa0ba902b7" || entry[gcpTraceSampledKey] != true {
+		t.Errorf("unexpected entry %v", entry)
+	}
+}
@@ -0,0 +1,172 @@
+package zlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/rs/zerolog"
+)
+
+// MaskingWriter is an io.Writer applying a masking configuration to each JSON line before writing it to the
+// underlying writer, so that entries logged through any API, including the embedded zerolog.Logger or libraries
+// sharing it, are masked at the sink. Lines that are not JSON objects are masked as messages.
+type MaskingWriter struct {
+	out io.Writer
+	// policy returns the current masking policy, which the Logger masking at its output replaces on Reload.
+	policy func() *maskingPolicy
+}
+
+// NewMaskingWriter wraps out with a MaskingWriter applying the given masking configuration.
+func NewMaskingWriter(out io.Writer, config MaskingConfig) *MaskingWriter {
+	policy := newMaskingPolicy(config)
+	return &MaskingWriter{out: out, policy: func() *maskingPolicy { return policy }}
+}
+
+// Write masks every line of p and writes them to the underlying writer.
+func (w *MaskingWriter) Write(p []byte) (int, error) {
+	if _, err := w.out.Write(w.mask(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteLevel masks every line of p and writes them to the underlying writer, keeping the level for a zerolog.LevelWriter.
+func (w *MaskingWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	lw, ok := w.out.(zerolog.LevelWriter)
+	if !ok {
+		return w.Write(p)
+	}
+	if _, err := lw.WriteLevel(level, w.mask(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// mask masks each line of p.
+func (w *MaskingWriter) mask(p []byte) []byte {
+	policy := w.policy()
+	if !policy.enabled {
+		return p
+	}
+	var buf bytes.Buffer
+	for len(p) > 0 {
+		line, rest, found := bytes.Cut(p, []byte{'\n'})
+		buf.Write(w.maskLine(policy.forEntry(), line))
+		if found {
+			buf.WriteByte('\n')
+		}
+		p = rest
+	}
+	return buf.Bytes()
+}
+
+// maskLine masks the fields of a JSON object line with the policy of the entry, keeping the order of its top-level keys.
+func (w *MaskingWriter) maskLine(p *maskingPolicy, line []byte) []byte {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return []byte(p.maskMessage(string(line)))
+	}
+	masked, err := w.maskObject(p, trimmed)
+	if err != nil {
+		return []byte(p.maskMessage(string(line)))
+	}
+	return masked
+}
+
+// maskObject decodes the top-level keys of a JSON object one by one and re-encodes them masked. In dry-run mode,
+// the fields that would have been masked are added in masked_fields, before the message when it is the last field,
+// so that it stays last for fieldNamesWriter.
+func (w *MaskingWriter) maskObject(p *maskingPolicy, object []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(object))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	lastKey, lastStart := "", 0
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		value, keep, err := w.maskEntry(p, key, raw)
+		if err != nil {
+			return nil, err
+		}
+		if !keep {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		lastKey, lastStart = key, buf.Len()
+		buf.Write(marshalJSON(key))
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if maskedFields := p.trace.list(); len(maskedFields) > 0 {
+		var message []byte
+		if lastKey == zerolog.MessageFieldName {
+			message = append(message, buf.Bytes()[lastStart:]...)
+			buf.Truncate(lastStart)
+		} else if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(marshalJSON(maskedFieldsKey))
+		buf.WriteByte(':')
+		buf.Write(marshalJSON(maskedFields))
+		if message != nil {
+			buf.WriteByte(',')
+			buf.Write(message)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// maskEntry masks the raw JSON value of a top-level key. It returns false when the entry must be dropped.
+func (w *MaskingWriter) maskEntry(p *maskingPolicy, key string, raw json.RawMessage) ([]byte, bool, error) {
+	mask, sensitive := p.match(nil, key)
+	if !sensitive && raw[0] != '"' && raw[0] != '{' && raw[0] != '[' {
+		return raw, true, nil
+	}
+
+	var value interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return nil, false, err
+	}
+	if sensitive {
+		masked, keep := maskValue(mask, value)
+		return marshalJSON(masked), keep, nil
+	}
+	if s, ok := value.(string); ok {
+		if key == zerolog.MessageFieldName {
+			return marshalJSON(p.maskMessage(s)), true, nil
+		}
+		return marshalJSON(p.maskString(fieldPath{key}, s)), true, nil
+	}
+	return marshalJSON(p.plainValue(p.child(nil, key), reflect.ValueOf(value), nil)), true, nil
+}
+
+// marshalJSON encodes a value as JSON without escaping HTML characters, like zerolog does.
+func marshalJSON(v interface{}) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return []byte(`null`)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})
+}
@@ -0,0 +1,68 @@
+package zlogs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// TestMaskingWriter verifies that JSON lines written through the writer are masked whichever API produced them.
+func TestMaskingWriter(t *testing.T) {
+	config := MaskingConfig{
+		Enabled:         true,
+		SensitiveFields: []string{"!product.name"},
+		Detectors:       []string{DetectorEmail},
+		Strategies:      map[string]MaskStrategy{"token": MaskDrop},
+	}
+
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"TopLevelKey", `{"severity":"info","password":"P@ss","id":12}` + "\n", `{"severity":"info","password":"***","id":12}` + "\n"},
+		{"NestedObject", `{"data":{"password":"P@ss","amount":10.50},"product":{"name":"Gold"}}` + "\n", `{"data":{"amount":10.50,"password":"***"},"product":{"name":"Gold"}}` + "\n"},
+		{"Array", `{"users":[{"cvc":123},"john@doe.com"]}` + "\n", `{"users":[{"cvc":"***"},"***"]}` + "\n"},
+		{"Message", `{"message":"login password=P@ss <ok>"}` + "\n", `{"message":"login password=*** <ok>"}` + "\n"},
+		{"DroppedKey", `{"token":"abc","id":1}` + "\n", `{"id":1}` + "\n"},
+		{"MultipleLines", `{"password":"a"}` + "\n" + `{"password":"b"}` + "\n", `{"password":"***"}` + "\n" + `{"password":"***"}` + "\n"},
+		{"PlainText", "password=P@ss\n", "password=***\n"},
+		{"InvalidJSON", `{"password":"P@ss"` + "\n", `{"password":"***"` + "\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := NewMaskingWriter(&buf, config).Write([]byte(tc.input))
+			if err != nil || n != len(tc.input) {
+				t.Fatalf("unexpected write result %d, %v", n, err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestMaskingWriterWithZerolog verifies that entries logged directly through zerolog are masked at the sink.
+func TestMaskingWriterWithZerolog(t *testing.T) {
+	var buf bytes.Buffer
+	zl := zerolog.New(NewMaskingWriter(&buf, MaskingConfig{Enabled: true}))
+	zl.Info().Str("password", "P@ss").Str("user", "jay").Msg("raw")
+
+	if got := buf.String(); !strings.Contains(got, `"password":"***","user":"jay"`) {
+		t.Errorf("expected masked password, got %q", got)
+	}
+}
+
+// TestAtOutputPolicy verifies that masking at the output leaves only the struct tags to the events.
+func TestAtOutputPolicy(t *testing.T) {
+	policy := newEventPolicy(MaskingConfig{Enabled: true, AtOutput: true})
+	if policy.isSensitive("password") {
+		t.Error("expected event policy to leave field masking to the writer")
+	}
+	if !policy.tagsEnabled {
+		t.Error("expected event policy to keep struct tag masking")
+	}
+}